@@ -0,0 +1,222 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"tienbm90/yml2fstab/fstab"
+	"tienbm90/yml2fstab/mount"
+	"tienbm90/yml2fstab/render"
+)
+
+type applyCommand struct {
+	out          string
+	backupSuffix string
+	pretty       bool
+	merge        bool
+	reconcile    bool
+	mountInfo    string
+	crypttab     string
+	autofsDir    string
+	unitDir      string
+}
+
+func (*applyCommand) Name() string { return "apply" }
+
+func (*applyCommand) Synopsis() string { return "Write the output, crypttab and autofs maps in place" }
+
+func (*applyCommand) Usage() string {
+	return `Usage: yml2fstab apply [-out path] [-backup-suffix suffix] [-pretty | -merge] [-reconcile]
+
+Renders every output before writing anything, then writes crypttab for encrypted entries,
+the autofs maps for autofs entries and finally the output at -out. The output replaces -out
+atomically through a temp file in the same directory, the previous file is kept with
+-backup-suffix appended. With -format systemd the units are written to -unit-dir instead.
+-out - prints the output, without a diff or backup. It is then meant for another system:
+crypttab and the autofs maps are only written when -crypttab or -autofs-dir is given, and
+the units are printed unless -unit-dir is given.
+-reconcile then mounts, unmounts or remounts the entries to match their state.`
+}
+
+func (c *applyCommand) SetFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.out, "out", "/etc/fstab", "Path to output file, - writes stdout and with -merge reads the existing fstab from stdin")
+	fs.StringVar(&c.backupSuffix, "backup-suffix", ".bak", "Suffix of the backup of the replaced output file. Empty disables the backup")
+	fs.BoolVar(&c.pretty, "pretty", false, "Write an aligned fstab with a header and the entry comments, only with -format fstab")
+	fs.BoolVar(&c.merge, "merge", false, "Merge the entries into the existing fstab at -out, keeping unmanaged lines and removing absent entries, and print the diff")
+	fs.BoolVar(&c.reconcile, "reconcile", false, "Mount, unmount or remount the entries to match their state after writing the fstab")
+	fs.StringVar(&c.mountInfo, "mountinfo", mount.DefaultMountInfoPath, "Path to the mountinfo of the running system")
+	fs.StringVar(&c.crypttab, "crypttab", "/etc/crypttab", "Path to the crypttab written for encrypted entries")
+	fs.StringVar(&c.autofsDir, "autofs-dir", "/etc", "Directory of auto.master and the autofs maps")
+	fs.StringVar(&c.unitDir, "unit-dir", "/etc/systemd/system", "Directory of the generated units with -format systemd")
+}
+
+func (c *applyCommand) paths() []*string {
+	// -autofs-dir is resolved by Run, auto.master needs it as the managed system sees it
+	return []*string{&c.out, &c.crypttab, &c.unitDir, &c.mountInfo}
+}
+
+func (c *applyCommand) Run(a *app, args []string) int {
+	if c.pretty && !a.requireFormat("-pretty", fstab.FormatFstab) {
+		return ExitError
+	}
+	if c.merge && !a.requireFormat("-merge", fstab.FormatFstab) {
+		return ExitError
+	}
+	if c.merge && c.pretty {
+		a.log.Printf("-merge is not supported with -pretty")
+		return ExitError
+	}
+	// the mounts of the running system are not the ones of the root
+	if c.reconcile && a.global.root != "" {
+		a.log.Printf("-reconcile is not supported with -root")
+		return ExitError
+	}
+	if c.reconcile && c.out == stdio {
+		a.log.Printf("-reconcile is not supported with -out %s", stdio)
+		return ExitError
+	}
+	if c.reconcile && !a.requireFormat("-reconcile", fstab.FormatFstab) {
+		return ExitError
+	}
+
+	autofsDir := c.autofsDir
+	if err := a.resolvePaths(&autofsDir); err != nil {
+		return a.fail("Root", err)
+	}
+	mapDir := c.autofsDir
+	if a.global.root != "" {
+		mapDir = filepath.Join("/", mapDir)
+	}
+
+	p, err := a.load()
+	if err != nil {
+		return a.fail("", err)
+	}
+
+	// render every output before writing anything
+	maps, err := render.NewAutofsMaps(p.autofs)
+	if err != nil {
+		return a.fail("", &stageError{stage: "Autofs", code: ExitInvalid, err: err})
+	}
+
+	var desired []*mount.DesiredMount
+	if c.reconcile {
+		desired, err = mount.NewDesiredMounts(append(p.configs, p.absent...))
+		if err != nil {
+			return a.fail("", &stageError{stage: "Render", code: ExitRender, err: err})
+		}
+	}
+
+	var header *fstab.FstabHeader
+	if c.pretty {
+		header = fstab.NewFstabHeader(a.inputName(), p.source, time.Now())
+	}
+
+	var out *output
+	var diff string
+	if c.merge {
+		existing, err := a.readExisting(c.out)
+		if err != nil {
+			return a.fail("Merge", err)
+		}
+		content, err := mergedContent(p, existing)
+		if err != nil {
+			return a.fail("", err)
+		}
+		diff, err = fstab.UnifiedDiff(c.out, existing, content)
+		if err != nil {
+			return a.fail("Diff", err)
+		}
+		out = &output{content: []byte(content)}
+	} else {
+		out, err = a.render(p, header)
+		if err != nil {
+			return a.fail("", err)
+		}
+	}
+
+	// every file is replaced atomically, but a failure leaves the ones written before it
+	written := make([]string, 0)
+
+	// crypttab is written first so the output never references a mapper name it does not open
+	if len(p.crypttab) > 0 && c.writes(a, "crypttab") {
+		err = render.WriteCrypttabFile(a.fs, p.crypttab, c.crypttab)
+		if err != nil {
+			return a.failWrite("Write crypttab", err, written)
+		}
+		written = append(written, c.crypttab)
+	}
+
+	if len(maps) > 0 && c.writes(a, "autofs-dir") {
+		err = render.WriteAutofsMaps(a.fs, maps, autofsDir, mapDir)
+		if err != nil {
+			return a.failWrite("Write autofs", err, written)
+		}
+		written = append(written, autofsDir)
+	}
+
+	if out.units != nil && c.out == stdio && !a.given["unit-dir"] {
+		if _, err := a.stdout.Write(formatUnits(out.units)); err != nil {
+			return a.fail("Write file", err)
+		}
+		return ExitOK
+	}
+	if out.units != nil {
+		err = render.WriteSystemdUnits(a.fs, out.units, c.unitDir)
+		if err != nil {
+			return a.failWrite("Write unit", err, written)
+		}
+		return ExitOK
+	}
+
+	// the output goes down a pipe, the diff would be mixed into it
+	if c.out == stdio {
+		if _, err := a.stdout.Write(out.content); err != nil {
+			return a.fail("Write file", err)
+		}
+		return ExitOK
+	}
+
+	backup := ""
+	if c.backupSuffix != "" {
+		backup = c.out + c.backupSuffix
+	}
+	err = fstab.WriteFileAtomic(a.fs, c.out, out.content, backup)
+	if err != nil {
+		return a.failWrite("Write file", err, written)
+	}
+	fmt.Fprint(a.stdout, diff)
+
+	if c.reconcile {
+		actions, err := mount.Reconcile(a.fs, mount.NewSyscallMounter(a.fs), desired, c.mountInfo)
+		for _, action := range actions {
+			fmt.Fprintln(a.stdout, action)
+		}
+		if err != nil {
+			return a.fail("Reconcile", err)
+		}
+	}
+	return ExitOK
+}
+
+// writes reports whether the file of the path flag name is written. With -out - the output is
+// for another system and the host files are left alone unless their path is given.
+func (c *applyCommand) writes(a *app, name string) bool {
+	if c.out != stdio || a.given[name] {
+		return true
+	}
+	a.log.Printf("Not writing -%s with -out %s, give it explicitly to write the file", name, stdio)
+	return false
+}
+
+// failWrite fails with err and logs the outputs written before it, they are not rolled back.
+func (a *app) failWrite(stage string, err error, written []string) int {
+	code := a.fail(stage, err)
+	if len(written) > 0 {
+		a.log.Printf("Written before the error: %s", strings.Join(written, ", "))
+	}
+	return code
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+
+	"tienbm90/yml2fstab/fstab"
+)
+
+type checkCommand struct {
+	out string
+}
+
+func (*checkCommand) Name() string { return "check" }
+
+func (*checkCommand) Synopsis() string { return "Report the drift of the installed fstab as JSON" }
+
+func (*checkCommand) Usage() string {
+	return `Usage: yml2fstab check [-out path]
+
+Compares the entries of the fstab at -out with the rendered ones and prints a JSON report
+of the missing, extra and modified entries. Exits 0 in sync, 1 on drift and 2 on error,
+the convention of monitoring plugins. Only supported with -format fstab.`
+}
+
+func (c *checkCommand) SetFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.out, "out", "/etc/fstab", "Path to the fstab checked, - reads stdin")
+}
+
+func (c *checkCommand) paths() []*string {
+	return []*string{&c.out}
+}
+
+func (c *checkCommand) Run(a *app, args []string) int {
+	if !a.requireFormat("check", fstab.FormatFstab) {
+		return fstab.CheckExitError
+	}
+	p, err := a.load()
+	if err != nil {
+		a.log.Print(err.Error())
+		return fstab.CheckExitError
+	}
+	in, err := a.open(c.out)
+	if err != nil {
+		a.log.Printf("Check error: %s", err.Error())
+		return fstab.CheckExitError
+	}
+	defer in.Close()
+	live, err := fstab.ReadFstab(in)
+	if err != nil {
+		a.log.Printf("Check error: %s", err.Error())
+		return fstab.CheckExitError
+	}
+	report := fstab.CheckDrift(c.out, p.entries, live)
+	err = fstab.WriteDriftReport(a.stdout, report)
+	if err != nil {
+		a.log.Printf("Check error: %s", err.Error())
+		return fstab.CheckExitError
+	}
+	return report.ExitCode()
+}
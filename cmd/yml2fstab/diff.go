@@ -0,0 +1,101 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"tienbm90/yml2fstab/fstab"
+	"tienbm90/yml2fstab/render"
+)
+
+type diffCommand struct {
+	out   string
+	merge bool
+}
+
+func (*diffCommand) Name() string { return "diff" }
+
+func (*diffCommand) Synopsis() string { return "Show how apply would change the output file" }
+
+func (*diffCommand) Usage() string {
+	return `Usage: yml2fstab diff [-out path] [-merge]
+
+Prints the unified diff between the file at -out and the rendered output, with -merge
+the output merged into the existing fstab as apply -merge writes it. Exits 0 without
+differences, 1 with differences and 2 on error. Not supported with -format systemd.`
+}
+
+func (c *diffCommand) SetFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.out, "out", "/etc/fstab", "Path to the file compared with the rendered output, - reads stdin")
+	fs.BoolVar(&c.merge, "merge", false, "Compare with the entries merged into the existing fstab, only with -format fstab")
+}
+
+func (c *diffCommand) paths() []*string {
+	return []*string{&c.out}
+}
+
+// mergedContent merges the entries into the existing fstab, removing the absent ones.
+func mergedContent(p *pipeline, existing string) (string, error) {
+	absent, err := fstab.RenderFstabLines(p.absent)
+	if err != nil {
+		return "", &stageError{stage: "Render", code: ExitRender, err: err}
+	}
+	merged, err := fstab.MergeFstab(existing, p.entries, absent)
+	if err != nil {
+		return "", &stageError{stage: "Merge", code: ExitParse, err: err}
+	}
+	return merged.Content(), nil
+}
+
+// readExisting returns the current content of path, empty when it does not exist yet.
+// - reads it from stdin.
+func (a *app) readExisting(path string) (string, error) {
+	existing, err := a.readFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	return string(existing), nil
+}
+
+func (c *diffCommand) Run(a *app, args []string) int {
+	if a.global.format == render.FormatSystemd {
+		a.log.Printf("diff is not supported with -format %s", render.FormatSystemd)
+		return ExitError
+	}
+	if c.merge && !a.requireFormat("-merge", fstab.FormatFstab) {
+		return ExitError
+	}
+	p, err := a.load()
+	if err != nil {
+		return a.fail("", err)
+	}
+	existing, err := a.readExisting(c.out)
+	if err != nil {
+		return a.fail("", err)
+	}
+
+	var content string
+	if c.merge {
+		content, err = mergedContent(p, existing)
+	} else {
+		var out *output
+		out, err = a.render(p, nil)
+		if out != nil {
+			content = string(out.content)
+		}
+	}
+	if err != nil {
+		return a.fail("", err)
+	}
+
+	diff, err := fstab.UnifiedDiff(c.out, existing, content)
+	if err != nil {
+		return a.fail("Diff", err)
+	}
+	if diff == "" {
+		return ExitOK
+	}
+	fmt.Fprint(a.stdout, diff)
+	return ExitChanged
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"flag"
+
+	"tienbm90/yml2fstab/render"
+)
+
+type explainCommand struct{}
+
+func (*explainCommand) Name() string { return "explain" }
+
+func (*explainCommand) Synopsis() string { return "Preview what systemd does with the entries at boot" }
+
+func (*explainCommand) Usage() string {
+	return `Usage: yml2fstab explain
+
+Prints the units systemd-fstab-generator creates from the rendered entries, their
+dependencies and whether a failure stops the boot. explain-boot is an alias of explain.`
+}
+
+func (*explainCommand) SetFlags(fs *flag.FlagSet) {}
+
+func (*explainCommand) Run(a *app, args []string) int {
+	p, err := a.load()
+	if err != nil {
+		return a.fail("", err)
+	}
+	err = render.WriteBootExplanations(a.stdout, render.ExplainBoot(p.entries))
+	if err != nil {
+		return a.fail("Explain", err)
+	}
+	return ExitOK
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"flag"
+	"time"
+
+	"tienbm90/yml2fstab/fstab"
+	"tienbm90/yml2fstab/render"
+)
+
+type generateCommand struct {
+	out    string
+	pretty bool
+}
+
+func (*generateCommand) Name() string { return "generate" }
+
+func (*generateCommand) Synopsis() string { return "Render the configuration to stdout or a file" }
+
+func (*generateCommand) Usage() string {
+	return `Usage: yml2fstab generate [-out path] [-pretty]
+
+Renders the configuration in the -format output and prints it, or writes it to -out.
+With -format systemd -out is the directory of the units. Nothing else is written,
+crypttab and the autofs maps are only written by apply.`
+}
+
+func (c *generateCommand) SetFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.out, "out", "", "Path to output file, stdout when empty or -")
+	fs.BoolVar(&c.pretty, "pretty", false, "Write an aligned fstab with a header and the entry comments, only with -format fstab")
+}
+
+func (c *generateCommand) paths() []*string {
+	return []*string{&c.out}
+}
+
+func (c *generateCommand) Run(a *app, args []string) int {
+	if c.pretty && !a.requireFormat("-pretty", fstab.FormatFstab) {
+		return ExitError
+	}
+	p, err := a.load()
+	if err != nil {
+		return a.fail("", err)
+	}
+
+	var header *fstab.FstabHeader
+	if c.pretty {
+		header = fstab.NewFstabHeader(a.inputName(), p.source, time.Now())
+	}
+	out, err := a.render(p, header)
+	if err != nil {
+		return a.fail("", err)
+	}
+
+	switch {
+	case isStdout(c.out) && out.units != nil:
+		_, err = a.stdout.Write(formatUnits(out.units))
+	case isStdout(c.out):
+		_, err = a.stdout.Write(out.content)
+	case out.units != nil:
+		err = render.WriteSystemdUnits(a.fs, out.units, c.out)
+	default:
+		err = fstab.WriteFile(a.fs, c.out, out.content)
+	}
+	if err != nil {
+		return a.fail("Write file", err)
+	}
+	return ExitOK
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"tienbm90/yml2fstab/config"
+	"tienbm90/yml2fstab/fstab"
+)
+
+type importCommand struct {
+	fstab string
+	out   string
+}
+
+func (*importCommand) Name() string { return "import" }
+
+func (*importCommand) Synopsis() string { return "Convert an existing fstab to a configuration file" }
+
+func (*importCommand) Usage() string {
+	return `Usage: yml2fstab import [-fstab path] [-out path]
+
+Reads the fstab at -fstab and prints the equivalent configuration, or writes it to -out.
+Comment lines right above an entry become its comment. What the configuration cannot
+express, a dump number, a pass other than the type default or an unknown swap option,
+is reported on stderr and left out.`
+}
+
+func (c *importCommand) SetFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.fstab, "fstab", "/etc/fstab", "Path to the fstab to import, - reads stdin")
+	fs.StringVar(&c.out, "out", "", "Path to the configuration file written, stdout when empty or -")
+}
+
+func (c *importCommand) paths() []*string {
+	return []*string{&c.fstab}
+}
+
+func (c *importCommand) Run(a *app, args []string) int {
+	if err := a.setup(); err != nil {
+		return a.fail("", err)
+	}
+	content, err := a.readFile(c.fstab)
+	if err != nil {
+		return a.fail("Import", err)
+	}
+	f := fstab.NewFstab()
+	if err := f.Unmarshal(content); err != nil {
+		return a.fail("", &stageError{stage: "Import", code: ExitParse, err: fmt.Errorf("%s: %w", c.fstab, err)})
+	}
+	imported, err := fstab.ImportFstab(f)
+	if err != nil {
+		return a.fail("", &stageError{stage: "Import", code: ExitParse, err: err})
+	}
+
+	configs := make([]*config.Config, 0, len(imported))
+	for _, i := range imported {
+		if len(i.Dropped) > 0 {
+			a.log.Printf("%s: not imported: %s", i.Line.Key(), strings.Join(i.Dropped, ", "))
+		}
+		configs = append(configs, i.Config)
+	}
+	data, err := config.MarshalConfigs(configs)
+	if err != nil {
+		return a.fail("", &stageError{stage: "Import", code: ExitRender, err: err})
+	}
+
+	if isStdout(c.out) {
+		_, err = a.stdout.Write(data)
+	} else {
+		err = fstab.WriteFile(a.fs, c.out, data)
+	}
+	if err != nil {
+		return a.fail("Write file", err)
+	}
+	return ExitOK
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+)
+
+// legacyModes are the mode flags of the command line before the commands, in the order they
+// were checked: the first one given picks the command, apply runs without any.
+var legacyModes = []struct{ flag, command string }{
+	{"explain-boot", "explain"},
+	{"status", "status"},
+	{"check", "check"},
+}
+
+// commandAliases are the former names of the commands.
+var commandAliases = map[string]string{
+	"explain-boot": "explain",
+}
+
+// legacyArgs translates a command line of the flags only interface, such as
+// yml2fstab -in input.yml -out /etc/fstab, to the command it ran. ok is false when args name
+// a command, or are not a valid legacy command line.
+func (a *app) legacyArgs(args []string) ([]string, bool) {
+	if len(args) == 0 {
+		return nil, false
+	}
+	fs := flag.NewFlagSet("yml2fstab", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	newGlobalOptions().register(fs)
+	(&applyCommand{}).SetFlags(fs)
+	for _, m := range legacyModes {
+		fs.Bool(m.flag, false, "")
+	}
+	// the fstab was written through this file, it is now a temp file next to -out
+	fs.String("tmp-file", "", "")
+	if err := fs.Parse(args); err != nil || fs.NArg() > 0 {
+		return nil, false
+	}
+
+	given := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+	name := "apply"
+	for _, m := range legacyModes {
+		if given[m.flag] {
+			name = m.command
+			break
+		}
+	}
+	c, _ := lookupCommand(name)
+	cfs := flag.NewFlagSet(name, flag.ContinueOnError)
+	newGlobalOptions().register(cfs)
+	c.SetFlags(cfs)
+
+	translated := []string{name}
+	fs.Visit(func(f *flag.Flag) {
+		switch {
+		case isLegacyMode(f.Name):
+		case cfs.Lookup(f.Name) == nil:
+			a.log.Printf("-%s is ignored by %s", f.Name, name)
+		default:
+			translated = append(translated, "-"+f.Name+"="+f.Value.String())
+		}
+	})
+	return translated, true
+}
+
+func isLegacyMode(name string) bool {
+	for _, m := range legacyModes {
+		if m.flag == name {
+			return true
+		}
+	}
+	return false
+}
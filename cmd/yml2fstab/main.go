@@ -0,0 +1,275 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"tienbm90/yml2fstab/config"
+	"tienbm90/yml2fstab/fstab"
+	"tienbm90/yml2fstab/plugin"
+	"tienbm90/yml2fstab/render"
+	"tienbm90/yml2fstab/vfs"
+)
+
+// Exit codes shared by every command but check, diff follows the diff(1) convention.
+const (
+	ExitOK = 0
+	// ExitChanged reports drift or differences.
+	ExitChanged = 1
+	// ExitError covers usage errors and failures without a more specific code.
+	ExitError = 2
+	// ExitRead is an input file that cannot be read.
+	ExitRead = 3
+	// ExitParse is a configuration that is not valid YAML or has a malformed field.
+	ExitParse = 4
+	// ExitInvalid is a configuration that parses but breaks a rule.
+	ExitInvalid = 5
+	// ExitRender is a configuration that cannot be expressed in the output format.
+	ExitRender = 6
+	// ExitWrite is an output file that could not be written.
+	ExitWrite = 7
+	// ExitMount is a failed mount, unmount or remount.
+	ExitMount = 8
+)
+
+// globalOptions are accepted before the command name and by every command.
+type globalOptions struct {
+	in            string
+	format        string
+	root          string
+	procFS        string
+	pluginPath    string
+	pluginTimeout time.Duration
+}
+
+func newGlobalOptions() *globalOptions {
+	return &globalOptions{
+		in:            "input.yml",
+		format:        fstab.FormatFstab,
+		procFS:        "/proc/filesystems",
+		pluginPath:    os.Getenv("YML2FSTAB_PLUGIN_PATH"),
+		pluginTimeout: plugin.DefaultPluginTimeout,
+	}
+}
+
+// register adds the global flags to fs, the current values are the defaults so flags
+// given before the command name are kept.
+func (g *globalOptions) register(fs *flag.FlagSet) {
+	fs.StringVar(&g.in, "in", g.in, "Path to configuration file, - reads stdin")
+	fs.StringVar(&g.format, "format", g.format, "Output format: fstab, bsd, vfstab, systemd, cloud-init, ignition, k8s-pv or nixos")
+	fs.StringVar(&g.root, "root", g.root, "Directory the files of the managed system are read and written in, for images and chroots")
+	fs.StringVar(&g.procFS, "proc-filesystems", g.procFS, "Path to the kernel filesystem list used to accept runtime types. Empty disables it")
+	fs.StringVar(&g.pluginPath, "plugin-path", g.pluginPath, "Colon separated directories searched for yml2fstab-type-<name> plugins, $YML2FSTAB_PLUGIN_PATH by default")
+	fs.DurationVar(&g.pluginTimeout, "plugin-timeout", g.pluginTimeout, "Timeout of a single plugin run")
+}
+
+// command is a subcommand of yml2fstab.
+type command interface {
+	Name() string
+	// Synopsis is the one line summary of the command list.
+	Synopsis() string
+	// Usage is the help text shown above the flags.
+	Usage() string
+	SetFlags(fs *flag.FlagSet)
+	Run(a *app, args []string) int
+}
+
+var commands = []command{
+	&generateCommand{},
+	&validateCommand{},
+	&diffCommand{},
+	&importCommand{},
+	&checkCommand{},
+	&applyCommand{},
+	&statusCommand{},
+	&explainCommand{},
+}
+
+func lookupCommand(name string) (command, bool) {
+	if alias, ok := commandAliases[name]; ok {
+		name = alias
+	}
+	for _, c := range commands {
+		if c.Name() == name {
+			return c, true
+		}
+	}
+	return nil, false
+}
+
+type app struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	log    *log.Logger
+	// fs holds every file read or written but the plugins
+	fs     vfs.FS
+	global *globalOptions
+	// given holds the command flags set on the command line, not left at their default
+	given map[string]bool
+	// stdinRead is set once an input named - has read stdin
+	stdinRead bool
+}
+
+func newApp(stdin io.Reader, stdout io.Writer, stderr io.Writer) *app {
+	return &app{
+		stdin:  stdin,
+		stdout: stdout,
+		stderr: stderr,
+		log:    log.New(stderr, "", log.LstdFlags),
+		fs:     vfs.NewOSFS(),
+		global: newGlobalOptions(),
+		given:  make(map[string]bool),
+	}
+}
+
+// CheckFormatValid accepts systemd and the name of every registered fstab dialect or renderer.
+func CheckFormatValid(f string) bool {
+	if f == render.FormatSystemd {
+		return true
+	}
+	if _, ok := render.LookupConfigRenderer(f); ok {
+		return true
+	}
+	_, ok := fstab.LookupDialect(f)
+	return ok
+}
+
+// requireFormat logs and fails unless the global format is one of formats.
+func (a *app) requireFormat(name string, formats ...string) bool {
+	for _, f := range formats {
+		if a.global.format == f {
+			return true
+		}
+	}
+	a.log.Printf("%s is only supported with -format %s", name, strings.Join(formats, ", "))
+	return false
+}
+
+func (a *app) usage() {
+	fmt.Fprintf(a.stderr, "Usage: yml2fstab [global flags] <command> [flags] [args]\n\nCommands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(a.stderr, "  %-10s %s\n", c.Name(), c.Synopsis())
+	}
+	fmt.Fprintf(a.stderr, "\nRun 'yml2fstab help <command>' for the flags of a command.\n\nGlobal flags:\n")
+	fs := flag.NewFlagSet("yml2fstab", flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+	newGlobalOptions().register(fs)
+	fs.PrintDefaults()
+	fmt.Fprintf(a.stderr, "\nExit codes: %d success, %d drift or differences, %d error, %d read error, %d parse error,\n"+
+		"%d invalid configuration, %d render error, %d write error, %d mount error. check exits %d on every error.\n",
+		ExitOK, ExitChanged, ExitError, ExitRead, ExitParse, ExitInvalid, ExitRender, ExitWrite, ExitMount, fstab.CheckExitError)
+}
+
+func (a *app) commandFlagSet(c command) *flag.FlagSet {
+	fs := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(a.stderr, "%s\n\nFlags:\n", strings.TrimSpace(c.Usage()))
+		fs.PrintDefaults()
+	}
+	a.global.register(fs)
+	c.SetFlags(fs)
+	return fs
+}
+
+// run executes the command line args and returns the exit code.
+func (a *app) run(args []string) int {
+	if legacy, ok := a.legacyArgs(args); ok {
+		a.log.Printf("Running yml2fstab without a command is deprecated, run: yml2fstab %s", strings.Join(legacy, " "))
+		args = legacy
+	}
+
+	fs := flag.NewFlagSet("yml2fstab", flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+	fs.Usage = a.usage
+	a.global.register(fs)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK
+		}
+		return ExitError
+	}
+	if fs.NArg() == 0 {
+		a.usage()
+		return ExitError
+	}
+
+	name, args := fs.Arg(0), fs.Args()[1:]
+	if name == "help" {
+		if len(args) == 0 {
+			a.usage()
+			return ExitOK
+		}
+		c, ok := lookupCommand(args[0])
+		if !ok {
+			a.log.Printf("Unknown command: %s", args[0])
+			return ExitError
+		}
+		a.commandFlagSet(c).Usage()
+		return ExitOK
+	}
+	c, ok := lookupCommand(name)
+	if !ok {
+		a.log.Printf("Unknown command: %s", name)
+		a.usage()
+		return ExitError
+	}
+
+	cfs := a.commandFlagSet(c)
+	if err := cfs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK
+		}
+		return ExitError
+	}
+	cfs.Visit(func(f *flag.Flag) {
+		a.given[f.Name] = true
+	})
+	if !CheckFormatValid(a.global.format) {
+		a.log.Printf("Unknown format: %s", a.global.format)
+		return ExitError
+	}
+	if err := a.checkRoot(); err != nil {
+		return a.fail("Root", err)
+	}
+	if pc, ok := c.(pathCommand); ok {
+		if err := a.resolvePaths(pc.paths()...); err != nil {
+			return a.fail("Root", err)
+		}
+	}
+	return c.Run(a, cfs.Args())
+}
+
+// setup registers the plugin and kernel filesystem types before any configuration is read.
+// Plugins come first so they can provide the types the kernel lists, but not the built-in ones.
+func (a *app) setup() error {
+	//register external filesystem types
+	err := plugin.RegisterPlugins(a.fs, a.log, a.global.pluginPath, a.global.pluginTimeout)
+	if err != nil {
+		return &stageError{stage: "Plugin discovery", err: err}
+	}
+
+	//accept the filesystem types supported by the running kernel, the one of -root
+	procFS := a.global.procFS
+	if err := a.resolvePaths(&procFS); err != nil {
+		return &stageError{stage: "Load filesystem types", err: err}
+	}
+	if procFS != "" {
+		err := config.DefaultFileSystemRegistry.LoadProcFileSystems(a.fs, procFS)
+		if err != nil && !os.IsNotExist(err) {
+			return &stageError{stage: "Load filesystem types", err: err}
+		}
+	}
+	return nil
+}
+
+func main() {
+	os.Exit(newApp(os.Stdin, os.Stdout, os.Stderr).run(os.Args[1:]))
+}
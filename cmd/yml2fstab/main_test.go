@@ -0,0 +1,417 @@
+package main
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	"tienbm90/yml2fstab/vfs"
+)
+
+const testConfig = `
+fstab:
+  /dev/sda1:
+    mount: /boot
+    type: xfs
+  192.168.4.5:
+    mount: /home
+    export: /var/nfs/home
+    type: nfs
+    options: [noexec, nosuid]
+swap:
+  /dev/sda3:
+    priority: 10
+`
+
+const testFstab = `/dev/sda1 /boot xfs defaults 0 0
+192.168.4.5:/var/nfs/home /home nfs noexec,nosuid 0 0
+/dev/sda3 swap swap pri=10 0 0
+`
+
+func writeTestConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "input.yml")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func runCommand(args ...string) (int, string, string) {
+	return runCommandWithInput("", args...)
+}
+
+func runCommandWithInput(stdin string, args ...string) (int, string, string) {
+	return runCommandFS(vfs.NewOSFS(), stdin, args...)
+}
+
+func runCommandFS(fsys vfs.FS, stdin string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	a := newApp(strings.NewReader(stdin), &stdout, &stderr)
+	a.fs = fsys
+	code := a.run(append([]string{"-proc-filesystems", ""}, args...))
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun(t *testing.T) {
+	t.Run("usage", func(t *testing.T) {
+		var usage bytes.Buffer
+		code := newApp(strings.NewReader(""), ioutil.Discard, &usage).run(nil)
+		assert.Equal(t, ExitError, code)
+		assert.Contains(t, usage.String(), "generate")
+
+		code, _, stderr := runCommand("help", "apply")
+		assert.Equal(t, ExitOK, code)
+		assert.Contains(t, stderr, "-backup-suffix")
+
+		code, _, _ = runCommand("apply", "-h")
+		assert.Equal(t, ExitOK, code)
+
+		code, _, stderr = runCommand("mount")
+		assert.Equal(t, ExitError, code)
+		assert.Contains(t, stderr, "Unknown command: mount")
+
+		code, _, _ = runCommand("generate", "-bogus")
+		assert.Equal(t, ExitError, code)
+		code, _, _ = runCommand("-format", "hdfs", "generate")
+		assert.Equal(t, ExitError, code)
+	})
+
+	t.Run("legacy flags", func(t *testing.T) {
+		fsys := vfs.NewMemoryFS()
+		assert.NoError(t, fsys.MkdirAll("/etc", 0755))
+		assert.NoError(t, vfs.WriteFile(fsys, "/input.yml", []byte(testConfig), 0644))
+
+		code, _, stderr := runCommandFS(fsys, "", "-in", "/input.yml", "-out", "/etc/fstab", "-tmp-file", "/tmp/fstab.temp")
+		assert.Equal(t, ExitOK, code)
+		assert.Contains(t, stderr, "-tmp-file is ignored by apply")
+		assert.Contains(t, stderr, "deprecated, run: yml2fstab apply -in=/input.yml -out=/etc/fstab -proc-filesystems=")
+		content, _ := vfs.ReadFile(fsys, "/etc/fstab")
+		assert.Equal(t, testFstab, string(content))
+
+		code, stdout, stderr := runCommandFS(fsys, "", "-in", "/input.yml", "-check", "-out", "/etc/fstab")
+		assert.Equal(t, ExitOK, code)
+		assert.Contains(t, stdout, `"in_sync"`)
+		assert.Contains(t, stderr, "run: yml2fstab check")
+
+		code, stdout, _ = runCommandFS(fsys, "", "-in", "/input.yml", "-explain-boot", "-out", "/etc/fstab")
+		assert.Equal(t, ExitOK, code)
+		_, explained, _ := runCommandFS(fsys, "", "-in", "/input.yml", "explain")
+		assert.Equal(t, explained, stdout)
+		// the subcommand kept its former name too
+		code, stdout, _ = runCommandFS(fsys, "", "-in", "/input.yml", "explain-boot")
+		assert.Equal(t, ExitOK, code)
+		assert.Equal(t, explained, stdout)
+
+		code, _, _ = runCommandFS(fsys, "", "-in", "/input.yml", "-bogus")
+		assert.Equal(t, ExitError, code)
+	})
+
+	t.Run("generate", func(t *testing.T) {
+		in := writeTestConfig(t, testConfig)
+		code, stdout, _ := runCommand("-in", in, "generate")
+		assert.Equal(t, ExitOK, code)
+		assert.Equal(t, testFstab, stdout)
+
+		// global flags are accepted after the command name too
+		code, stdout, _ = runCommand("generate", "-in", in, "-format", "systemd")
+		assert.Equal(t, ExitOK, code)
+		assert.Contains(t, stdout, "# boot.mount\n")
+
+		out := filepath.Join(t.TempDir(), "fstab")
+		code, _, _ = runCommand("-in", in, "generate", "-out", out)
+		assert.Equal(t, ExitOK, code)
+		content, _ := ioutil.ReadFile(out)
+		assert.Equal(t, testFstab, string(content))
+
+		code, _, _ = runCommand("-in", in, "generate", "-out", filepath.Join(t.TempDir(), "missing", "fstab"))
+		assert.Equal(t, ExitWrite, code)
+
+		code, _, _ = runCommand("-in", in, "-format", "bsd", "generate", "-pretty")
+		assert.Equal(t, ExitError, code)
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		code, stdout, _ := runCommand("-in", writeTestConfig(t, testConfig), "validate")
+		assert.Equal(t, ExitOK, code)
+		assert.Contains(t, stdout, "3 entries valid")
+
+		code, _, stderr := runCommand("-in", writeTestConfig(t, "fstab:\n  /dev/sda1:\n    mount: boot\n    type: xfs\n"), "validate")
+		assert.Equal(t, ExitInvalid, code)
+		assert.Contains(t, stderr, "Validation error")
+
+		code, _, stderr = runCommand("-in", writeTestConfig(t, "fstab: ["), "validate")
+		assert.Equal(t, ExitParse, code)
+		assert.Contains(t, stderr, "Parser error")
+
+		code, _, _ = runCommand("-in", writeTestConfig(t, "fstab:\n  /dev/sda1:\n    type: xfs\n"), "validate")
+		assert.Equal(t, ExitParse, code)
+
+		code, _, _ = runCommand("-in", filepath.Join(t.TempDir(), "missing.yml"), "validate")
+		assert.Equal(t, ExitRead, code)
+	})
+
+	t.Run("diff, apply and check", func(t *testing.T) {
+		in := writeTestConfig(t, testConfig)
+		out := filepath.Join(t.TempDir(), "fstab")
+		assert.NoError(t, ioutil.WriteFile(out, []byte("/dev/sda1 /boot xfs defaults 0 0\n"), 0644))
+
+		code, stdout, _ := runCommand("-in", in, "diff", "-out", out)
+		assert.Equal(t, ExitChanged, code)
+		assert.Contains(t, stdout, "+/dev/sda3 swap swap pri=10 0 0\n")
+
+		code, _, _ = runCommand("-in", in, "check", "-out", out)
+		assert.Equal(t, ExitChanged, code)
+
+		code, _, _ = runCommand("-in", in, "apply", "-out", out)
+		assert.Equal(t, ExitOK, code)
+		content, _ := ioutil.ReadFile(out)
+		assert.Equal(t, testFstab, string(content))
+		backup, _ := ioutil.ReadFile(out + ".bak")
+		assert.Equal(t, "/dev/sda1 /boot xfs defaults 0 0\n", string(backup))
+
+		code, stdout, _ = runCommand("-in", in, "diff", "-out", out)
+		assert.Equal(t, ExitOK, code)
+		assert.Equal(t, "", stdout)
+
+		code, stdout, _ = runCommand("-in", in, "check", "-out", out)
+		assert.Equal(t, ExitOK, code)
+		assert.Contains(t, stdout, `"in_sync": true`)
+
+		code, _, _ = runCommand("-in", in, "-format", "nixos", "check", "-out", out)
+		assert.Equal(t, ExitError, code)
+	})
+
+	t.Run("mount point with a space", func(t *testing.T) {
+		in := writeTestConfig(t, "fstab:\n  /dev/sdc1:\n    mount: /srv/my data\n    type: xfs\n")
+		for _, pretty := range []string{"-pretty=false", "-pretty"} {
+			out := filepath.Join(t.TempDir(), "fstab")
+			code, _, stderr := runCommand("-in", in, "apply", pretty, "-out", out)
+			assert.Equal(t, ExitOK, code, stderr)
+			content, _ := ioutil.ReadFile(out)
+			assert.Contains(t, string(content), `/srv/my\040data`)
+
+			code, stdout, stderr := runCommand("-in", in, "check", "-out", out)
+			assert.Equal(t, ExitOK, code, stderr)
+			assert.Contains(t, stdout, `"in_sync": true`)
+		}
+	})
+
+	t.Run("import", func(t *testing.T) {
+		dir := t.TempDir()
+		existing := filepath.Join(dir, "fstab")
+		assert.NoError(t, ioutil.WriteFile(existing, []byte("# boot partition\n"+testFstab+"/dev/sdb1 /data ext4 defaults 1 2\n"), 0644))
+
+		code, stdout, stderr := runCommand("import", "-fstab", existing)
+		assert.Equal(t, ExitOK, code)
+		assert.Contains(t, stdout, "comment: boot partition")
+		assert.Contains(t, stderr, "/data: not imported: dump 1")
+
+		in := writeTestConfig(t, stdout)
+		code, stdout, _ = runCommand("-in", in, "generate")
+		assert.Equal(t, ExitOK, code)
+		assert.Contains(t, stdout, testFstab[:len("/dev/sda1 /boot xfs defaults 0 0\n")])
+		assert.Contains(t, stdout, "/dev/sdb1 /data ext4 defaults 0 2\n")
+	})
+
+	t.Run("stdin and stdout", func(t *testing.T) {
+		code, stdout, _ := runCommandWithInput(testConfig, "-in", "-", "generate", "-out", "-")
+		assert.Equal(t, ExitOK, code)
+		assert.Equal(t, testFstab, stdout)
+
+		code, stdout, _ = runCommandWithInput(testConfig, "-in", "-", "generate", "-pretty")
+		assert.Equal(t, ExitOK, code)
+		assert.Contains(t, stdout, "# Source:    stdin\n")
+
+		code, stdout, _ = runCommandWithInput(testConfig, "-in", "-", "apply", "-out", "-")
+		assert.Equal(t, ExitOK, code)
+		assert.Equal(t, testFstab, stdout)
+
+		code, _, stderr := runCommandWithInput("fstab: [", "-in", "-", "validate")
+		assert.Equal(t, ExitParse, code)
+		assert.Contains(t, stderr, "stdin: ")
+
+		in := writeTestConfig(t, testConfig)
+		code, stdout, _ = runCommandWithInput("/dev/sda1 /boot xfs defaults 0 0\n", "-in", in, "diff", "-out", "-")
+		assert.Equal(t, ExitChanged, code)
+		assert.Contains(t, stdout, "+/dev/sda3 swap swap pri=10 0 0\n")
+
+		code, stdout, _ = runCommandWithInput(testFstab, "-in", in, "check", "-out", "-")
+		assert.Equal(t, ExitOK, code)
+		assert.Contains(t, stdout, `"in_sync": true`)
+
+		code, stdout, _ = runCommandWithInput("# local\n/dev/sdb1 /data ext4 defaults 0 0\n", "-in", in, "apply", "-merge", "-out", "-")
+		assert.Equal(t, ExitOK, code)
+		assert.Contains(t, stdout, "# local\n/dev/sdb1 /data ext4 defaults 0 0\n")
+		assert.Contains(t, stdout, "/dev/sda3 swap swap pri=10 0 0\n")
+
+		code, stdout, _ = runCommandWithInput(testFstab, "import", "-fstab", "-", "-out", "-")
+		assert.Equal(t, ExitOK, code)
+		assert.Contains(t, stdout, "/dev/sda1:")
+
+		// stdin cannot be both inputs
+		code, _, stderr = runCommandWithInput(testConfig, "-in", "-", "diff", "-out", "-")
+		assert.Equal(t, ExitError, code)
+		assert.Contains(t, stderr, "stdin is already read")
+
+		code, _, _ = runCommandWithInput(testConfig, "-in", "-", "apply", "-out", "-", "-reconcile")
+		assert.Equal(t, ExitError, code)
+	})
+}
+
+const testEncryptedConfig = `
+fstab:
+  data:
+    mount: /srv/data
+    type: xfs
+    encryption:
+      device: /dev/sdb2
+`
+
+const testAutofsConfig = `
+  192.168.4.5:
+    mount: /srv/archive
+    export: /archive
+    type: nfs
+    automount: autofs
+`
+
+const testExisting = "# local\n/dev/sdb1 /data ext4 defaults 0 0\n"
+
+// newTestFS returns a file system holding the test configuration at /input.yml and
+// testExisting at /etc/fstab.
+func newTestFS(t *testing.T) *vfs.MemoryFS {
+	fsys := vfs.NewMemoryFS()
+	assert.NoError(t, fsys.MkdirAll("/etc", 0755))
+	assert.NoError(t, vfs.WriteFile(fsys, "/input.yml", []byte(testConfig), 0644))
+	assert.NoError(t, vfs.WriteFile(fsys, "/etc/fstab", []byte(testExisting), 0644))
+	return fsys
+}
+
+func TestFaults(t *testing.T) {
+	t.Run("merge", func(t *testing.T) {
+		fsys := newTestFS(t)
+		code, stdout, _ := runCommandFS(fsys, "", "-in", "/input.yml", "apply", "-merge")
+		assert.Equal(t, ExitOK, code)
+		assert.Contains(t, stdout, "+/dev/sda3 swap swap pri=10 0 0\n")
+		content, _ := vfs.ReadFile(fsys, "/etc/fstab")
+		assert.Equal(t, testExisting+testFstab, string(content))
+		backup, _ := vfs.ReadFile(fsys, "/etc/fstab.bak")
+		assert.Equal(t, testExisting, string(backup))
+		assert.Equal(t, []string{"/etc", "/etc/fstab", "/etc/fstab.bak", "/input.yml"}, fsys.Paths())
+	})
+
+	faults := []struct {
+		name    string
+		op      vfs.Op
+		pattern string
+		err     error
+		code    int
+	}{
+		{"existing fstab not readable", vfs.OpOpen, "/etc/fstab", syscall.EACCES, ExitRead},
+		{"configuration not readable", vfs.OpRead, "/input.yml", syscall.EIO, ExitRead},
+		{"disk full", vfs.OpWrite, "/etc/.fstab.tmp-*", syscall.ENOSPC, ExitWrite},
+		{"backup", vfs.OpOpen, "/etc/.fstab.bak.tmp-*", syscall.EACCES, ExitWrite},
+		{"read-only", vfs.OpRename, "/etc/fstab", syscall.EROFS, ExitWrite},
+	}
+	for _, f := range faults {
+		t.Run(f.name, func(t *testing.T) {
+			fsys := newTestFS(t)
+			fsys.Fail(f.op, f.pattern, f.err)
+			code, stdout, stderr := runCommandFS(fsys, "", "-in", "/input.yml", "apply", "-merge")
+			assert.Equal(t, f.code, code)
+			assert.Empty(t, stdout)
+			assert.Contains(t, stderr, f.err.Error())
+
+			fsys.ClearFaults()
+			content, _ := vfs.ReadFile(fsys, "/etc/fstab")
+			assert.Equal(t, testExisting, string(content))
+			for _, p := range fsys.Paths() {
+				assert.False(t, strings.Contains(p, ".tmp-"), p)
+			}
+		})
+	}
+
+	t.Run("written before the error", func(t *testing.T) {
+		fsys := newTestFS(t)
+		assert.NoError(t, vfs.WriteFile(fsys, "/input.yml", []byte(testEncryptedConfig), 0644))
+		fsys.Fail(vfs.OpRename, "/etc/fstab", syscall.EROFS)
+
+		code, _, stderr := runCommandFS(fsys, "", "-in", "/input.yml", "apply", "-crypttab", "/etc/crypttab")
+		assert.Equal(t, ExitWrite, code)
+		assert.Contains(t, stderr, "Written before the error: /etc/crypttab")
+		fsys.ClearFaults()
+		content, _ := vfs.ReadFile(fsys, "/etc/fstab")
+		assert.Equal(t, testExisting, string(content))
+	})
+
+	t.Run("legacy flags", func(t *testing.T) {
+		fsys := vfs.NewMemoryFS()
+		assert.NoError(t, fsys.MkdirAll("/etc", 0755))
+		assert.NoError(t, vfs.WriteFile(fsys, "/input.yml", []byte(testConfig), 0644))
+
+		code, _, stderr := runCommandFS(fsys, "", "-in", "/input.yml", "-out", "/etc/fstab", "-tmp-file", "/tmp/fstab.temp")
+		assert.Equal(t, ExitOK, code)
+		assert.Contains(t, stderr, "-tmp-file is ignored by apply")
+		assert.Contains(t, stderr, "deprecated, run: yml2fstab apply -in=/input.yml -out=/etc/fstab -proc-filesystems=")
+		content, _ := vfs.ReadFile(fsys, "/etc/fstab")
+		assert.Equal(t, testFstab, string(content))
+
+		code, stdout, stderr := runCommandFS(fsys, "", "-in", "/input.yml", "-check", "-out", "/etc/fstab")
+		assert.Equal(t, ExitOK, code)
+		assert.Contains(t, stdout, `"in_sync"`)
+		assert.Contains(t, stderr, "run: yml2fstab check")
+
+		code, stdout, _ = runCommandFS(fsys, "", "-in", "/input.yml", "-explain-boot", "-out", "/etc/fstab")
+		assert.Equal(t, ExitOK, code)
+		_, explained, _ := runCommandFS(fsys, "", "-in", "/input.yml", "explain")
+		assert.Equal(t, explained, stdout)
+		// the subcommand kept its former name too
+		code, stdout, _ = runCommandFS(fsys, "", "-in", "/input.yml", "explain-boot")
+		assert.Equal(t, ExitOK, code)
+		assert.Equal(t, explained, stdout)
+
+		code, _, _ = runCommandFS(fsys, "", "-in", "/input.yml", "-bogus")
+		assert.Equal(t, ExitError, code)
+	})
+
+	t.Run("generate", func(t *testing.T) {
+		fsys := newTestFS(t)
+		fsys.Fail(vfs.OpClose, "/.out.tmp-*", syscall.ENOSPC)
+		code, _, _ := runCommandFS(fsys, "", "-in", "/input.yml", "generate", "-out", "/out")
+		assert.Equal(t, ExitWrite, code)
+	})
+}
+
+func TestStdoutHostFiles(t *testing.T) {
+	t.Run("side files are skipped", func(t *testing.T) {
+		fsys := newTestFS(t)
+		code, stdout, stderr := runCommandFS(fsys, testEncryptedConfig+testAutofsConfig, "-in", "-", "apply", "-out", "-")
+		assert.Equal(t, ExitOK, code, stderr)
+		assert.Equal(t, "/dev/mapper/data /srv/data xfs defaults 0 0\n", stdout)
+		assert.Contains(t, stderr, "Not writing -crypttab with -out -")
+		assert.Contains(t, stderr, "Not writing -autofs-dir with -out -")
+		assert.Equal(t, []string{"/etc", "/etc/fstab", "/input.yml"}, fsys.Paths())
+	})
+
+	t.Run("side files given explicitly", func(t *testing.T) {
+		fsys := newTestFS(t)
+		code, _, stderr := runCommandFS(fsys, testEncryptedConfig+testAutofsConfig, "-in", "-", "apply", "-out", "-",
+			"-crypttab", "/etc/crypttab", "-autofs-dir", "/etc")
+		assert.Equal(t, ExitOK, code, stderr)
+		assert.Empty(t, stderr)
+		content, _ := vfs.ReadFile(fsys, "/etc/crypttab")
+		assert.Contains(t, string(content), "data /dev/sdb2")
+		_, err := fsys.Stat("/etc/auto.master")
+		assert.NoError(t, err)
+	})
+
+	t.Run("units are printed", func(t *testing.T) {
+		fsys := newTestFS(t)
+		code, stdout, stderr := runCommandFS(fsys, "", "-in", "/input.yml", "-format", "systemd", "apply", "-out", "-")
+		assert.Equal(t, ExitOK, code, stderr)
+		assert.Contains(t, stdout, "boot.mount")
+		assert.Equal(t, []string{"/etc", "/etc/fstab", "/input.yml"}, fsys.Paths())
+	})
+}
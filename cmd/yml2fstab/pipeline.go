@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"tienbm90/yml2fstab/config"
+	"tienbm90/yml2fstab/fstab"
+	"tienbm90/yml2fstab/mount"
+	"tienbm90/yml2fstab/render"
+	"tienbm90/yml2fstab/validate"
+)
+
+// stageError names the step of a command that failed. code is the exit code used when
+// the cause does not map to one.
+type stageError struct {
+	stage string
+	code  int
+	err   error
+}
+
+func (e *stageError) Error() string {
+	return fmt.Sprintf("%s error: %s", e.stage, e.err)
+}
+
+func (e *stageError) Unwrap() error {
+	return e.err
+}
+
+// exitCode maps the typed errors of the packages to the exit codes.
+func exitCode(err error) int {
+	var perr *config.ParseError
+	var pathErr *fs.PathError
+	var serr *stageError
+	switch {
+	case errors.Is(err, fstab.ErrWrite):
+		return ExitWrite
+	case errors.Is(err, mount.ErrMount):
+		return ExitMount
+	case errors.As(err, &perr):
+		return ExitParse
+	case errors.Is(err, validate.ErrInvalidConfig):
+		return ExitInvalid
+	case errors.Is(err, fstab.ErrUnsupported):
+		return ExitRender
+	case errors.As(err, &pathErr):
+		return ExitRead
+	case errors.As(err, &serr) && serr.code != 0:
+		return serr.code
+	}
+	return ExitError
+}
+
+// fail logs err, under stage when it is not empty, and returns its exit code.
+func (a *app) fail(stage string, err error) int {
+	if stage != "" {
+		err = &stageError{stage: stage, err: err}
+	}
+	a.log.Print(err.Error())
+	return exitCode(err)
+}
+
+// pipeline is what every command renders from the configuration file.
+type pipeline struct {
+	// configs are written to the output, without the absent and autofs entries
+	configs  []*config.Config
+	absent   []*config.Config
+	autofs   []*config.Config
+	crypttab []string
+	entries  []*fstab.FstabLine
+	// source is the YAML read, the checksum of the fstab header
+	source []byte
+}
+
+// load reads, validates and renders the configuration file, stdin with -in -.
+func (a *app) load() (*pipeline, error) {
+	if err := a.setup(); err != nil {
+		return nil, err
+	}
+
+	//read config from file or stdin
+	in, err := a.open(a.global.in)
+	if err != nil {
+		return nil, &stageError{stage: "Parser", err: err}
+	}
+	defer in.Close()
+	var source bytes.Buffer
+	configs, err := config.ReadConfigs(io.TeeReader(in, &source))
+	var perr *config.ParseError
+	if errors.As(err, &perr) {
+		perr.Path = a.inputName()
+	}
+	if err != nil {
+		return nil, &stageError{stage: "Parser", err: err}
+	}
+
+	err = validate.ValidateConfigs(configs)
+	if err != nil {
+		return nil, &stageError{stage: "Validation", err: err}
+	}
+	p := &pipeline{source: source.Bytes()}
+	// absent entries are not written, they are only unmounted by apply -reconcile
+	configs, p.absent = config.SplitAbsentConfigs(configs)
+
+	// encrypted volumes are opened from crypttab before their mapper device is mounted
+	p.crypttab, err = render.NewCrypttabEntries(configs)
+	if err != nil {
+		return nil, &stageError{stage: "Crypttab", code: ExitInvalid, err: err}
+	}
+
+	// autofs entries go to the autofs maps instead of fstab
+	p.configs, p.autofs = config.SplitAutofsConfigs(configs)
+
+	// create fstab entries
+	p.entries, err = fstab.RenderFstabLines(p.configs)
+	if err != nil {
+		return nil, &stageError{stage: "Render", code: ExitRender, err: err}
+	}
+	return p, nil
+}
+
+// output is the rendered main output, units for systemd and content for every other format.
+type output struct {
+	content []byte
+	units   []*render.SystemdUnitFile
+}
+
+// render renders the main output in the global format, the pretty fstab with a header.
+func (a *app) render(p *pipeline, header *fstab.FstabHeader) (*output, error) {
+	if header != nil {
+		return &output{content: []byte(fstab.FormatPrettyFstab(header, p.entries))}, nil
+	}
+	if dialect, ok := fstab.LookupDialect(a.global.format); ok {
+		entries, err := fstab.ConvertFstabLines(dialect, p.entries)
+		if err != nil {
+			return nil, &stageError{stage: "Convert", code: ExitRender, err: err}
+		}
+		return &output{content: []byte(fstab.FormatDialectFstab(dialect, entries))}, nil
+	}
+	if renderer, ok := render.LookupConfigRenderer(a.global.format); ok {
+		content, err := renderer.Render(p.configs)
+		if err != nil {
+			return nil, &stageError{stage: "Render", code: ExitRender, err: err}
+		}
+		return &output{content: content}, nil
+	}
+	units, err := render.NewSystemdUnits(p.entries)
+	if err != nil {
+		return nil, &stageError{stage: "Unit", code: ExitRender, err: err}
+	}
+	return &output{units: units}, nil
+}
+
+// formatUnits concatenates units for a terminal, each under a comment naming its file.
+func formatUnits(units []*render.SystemdUnitFile) []byte {
+	var b strings.Builder
+	for i, unit := range units {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "# %s\n%s", filepath.Base(unit.Name), unit.Content)
+	}
+	return []byte(b.String())
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"tienbm90/yml2fstab/vfs"
+)
+
+// pathCommand is a command whose flags name files of the managed system, they are resolved
+// inside -root before it runs.
+type pathCommand interface {
+	// paths returns the flags holding such files
+	paths() []*string
+}
+
+// checkRoot fails unless the -root, when given, is a directory.
+func (a *app) checkRoot() error {
+	if a.global.root == "" {
+		return nil
+	}
+	info, err := a.fs.Stat(a.global.root)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", a.global.root)
+	}
+	return nil
+}
+
+// resolvePaths rewrites every path to its location inside -root. Empty paths and - are kept.
+func (a *app) resolvePaths(paths ...*string) error {
+	if a.global.root == "" {
+		return nil
+	}
+	for _, path := range paths {
+		if *path == "" || *path == stdio {
+			continue
+		}
+		resolved, err := resolveRoot(a.fs, a.global.root, *path)
+		if err != nil {
+			return err
+		}
+		*path = resolved
+	}
+	return nil
+}
+
+// resolveRoot returns path inside root as a chroot sees it: relative paths start at root,
+// and .. and absolute symlink targets are resolved against root, so the result never leaves
+// it. The components that do not exist yet are joined as they are.
+func resolveRoot(fsys vfs.FS, root string, path string) (string, error) {
+	resolved, err := vfs.Resolve("/"+path, true, vfs.LinkReader(fsys, root))
+	if err == syscall.ELOOP {
+		return "", &os.PathError{Op: "resolve", Path: path, Err: err}
+	}
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, resolved), nil
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tienbm90/yml2fstab/vfs"
+)
+
+func TestResolveRoot(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		root := t.TempDir()
+		assert.NoError(t, os.MkdirAll(filepath.Join(root, "etc"), 0755))
+
+		for path, expected := range map[string]string{
+			"/etc/fstab":            "/etc/fstab",
+			"etc/fstab":             "/etc/fstab",
+			"/etc/../../../fstab":   "/fstab",
+			"/missing/dir/../fstab": "/missing/fstab",
+		} {
+			resolved, err := resolveRoot(vfs.NewOSFS(), root, path)
+			assert.NoError(t, err)
+			assert.Equal(t, filepath.Join(root, expected), resolved, path)
+		}
+	})
+
+	t.Run("symlinks stay inside the root", func(t *testing.T) {
+		root := t.TempDir()
+		assert.NoError(t, os.MkdirAll(filepath.Join(root, "usr", "etc"), 0755))
+		// an absolute link as found in images, it names /usr/etc of the image, not of the host
+		assert.NoError(t, os.Symlink("/usr/etc", filepath.Join(root, "etc")))
+		assert.NoError(t, os.Symlink("../../../../fstab.real", filepath.Join(root, "usr", "etc", "fstab")))
+
+		resolved, err := resolveRoot(vfs.NewOSFS(), root, "/etc/crypttab")
+		assert.NoError(t, err)
+		assert.Equal(t, filepath.Join(root, "usr", "etc", "crypttab"), resolved)
+
+		resolved, err = resolveRoot(vfs.NewOSFS(), root, "/etc/fstab")
+		assert.NoError(t, err)
+		assert.Equal(t, filepath.Join(root, "fstab.real"), resolved)
+	})
+
+	t.Run("loop", func(t *testing.T) {
+		root := t.TempDir()
+		assert.NoError(t, os.Symlink("/b", filepath.Join(root, "a")))
+		assert.NoError(t, os.Symlink("/a", filepath.Join(root, "b")))
+		_, err := resolveRoot(vfs.NewOSFS(), root, "/a/fstab")
+		assert.Error(t, err)
+	})
+}
+
+func TestRootFlag(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		in := writeTestConfig(t, testConfig)
+		root := t.TempDir()
+		assert.NoError(t, os.MkdirAll(filepath.Join(root, "etc"), 0755))
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(root, "etc", "fstab"), []byte("/dev/sda1 /boot xfs defaults 0 0\n"), 0644))
+
+		code, _, _ := runCommand("-in", in, "-root", root, "diff")
+		assert.Equal(t, ExitChanged, code)
+
+		code, _, _ = runCommand("-in", in, "-root", root, "apply")
+		assert.Equal(t, ExitOK, code)
+		content, _ := ioutil.ReadFile(filepath.Join(root, "etc", "fstab"))
+		assert.Equal(t, testFstab, string(content))
+		backup, _ := ioutil.ReadFile(filepath.Join(root, "etc", "fstab.bak"))
+		assert.Equal(t, "/dev/sda1 /boot xfs defaults 0 0\n", string(backup))
+
+		code, _, _ = runCommand("-in", in, "--root", root, "check")
+		assert.Equal(t, ExitOK, code)
+
+		code, stdout, _ := runCommand("--root", root, "import")
+		assert.Equal(t, ExitOK, code)
+		assert.Contains(t, stdout, "/dev/sda1:")
+	})
+
+	t.Run("side files name the paths of the root", func(t *testing.T) {
+		fsys := vfs.NewMemoryFS()
+		assert.NoError(t, fsys.MkdirAll("/image/etc/keys", 0755))
+		assert.NoError(t, fsys.MkdirAll("/image/etc/systemd/system", 0755))
+		config := strings.Replace(testEncryptedConfig, "device: /dev/sdb2", "device: /dev/sdb2\n      key: /etc/keys/data.key", 1)
+		assert.NoError(t, vfs.WriteFile(fsys, "/input.yml", []byte(config+testAutofsConfig), 0644))
+
+		code, _, stderr := runCommandFS(fsys, "", "-in", "/input.yml", "-root", "/image", "apply")
+		assert.Equal(t, ExitOK, code, stderr)
+		master, _ := vfs.ReadFile(fsys, "/image/etc/auto.master")
+		assert.Contains(t, string(master), "/- /etc/auto.nfs\n")
+		assert.NotContains(t, string(master), "/image")
+		crypttab, _ := vfs.ReadFile(fsys, "/image/etc/crypttab")
+		assert.Equal(t, "data /dev/sdb2 /etc/keys/data.key luks\n", string(crypttab))
+
+		code, _, stderr = runCommandFS(fsys, "", "-in", "/input.yml", "-root", "/image", "-format", "systemd", "apply")
+		assert.Equal(t, ExitOK, code, stderr)
+		unit, _ := vfs.ReadFile(fsys, "/image/etc/systemd/system/srv-data.mount")
+		assert.Contains(t, string(unit), "What=/dev/mapper/data\n")
+		for _, p := range fsys.Paths() {
+			if !strings.HasPrefix(p, "/image/etc/systemd/system/") {
+				continue
+			}
+			content, err := vfs.ReadFile(fsys, p)
+			if err != nil {
+				// a directory
+				continue
+			}
+			assert.NotContains(t, string(content), "/image", p)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		in := writeTestConfig(t, testConfig)
+		code, _, _ := runCommand("-in", in, "-root", filepath.Join(t.TempDir(), "missing"), "apply")
+		assert.Equal(t, ExitRead, code)
+
+		code, _, _ = runCommand("-in", in, "-root", in, "apply")
+		assert.Equal(t, ExitError, code)
+
+		code, _, stderr := runCommand("-in", in, "-root", t.TempDir(), "apply", "-reconcile")
+		assert.Equal(t, ExitError, code)
+		assert.Contains(t, stderr, "-reconcile is not supported with -root")
+
+		code, _, stderr = runCommand("-in", in, "-root", t.TempDir(), "status")
+		assert.Equal(t, ExitError, code)
+		assert.Contains(t, stderr, "status is not supported with -root")
+	})
+}
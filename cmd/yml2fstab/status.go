@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+
+	"tienbm90/yml2fstab/mount"
+)
+
+type statusCommand struct {
+	mountInfo string
+}
+
+func (*statusCommand) Name() string { return "status" }
+
+func (*statusCommand) Synopsis() string { return "Show which entries are mounted and how" }
+
+func (*statusCommand) Usage() string {
+	return `Usage: yml2fstab status [-mountinfo path]
+
+Prints whether each entry is mounted and how the mount differs from the rendered line,
+the options added by the kernel and the ones that were not applied. Not supported with -root,
+the device tags are resolved on the running system.`
+}
+
+func (c *statusCommand) SetFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.mountInfo, "mountinfo", mount.DefaultMountInfoPath, "Path to the mountinfo of the running system")
+}
+
+func (c *statusCommand) paths() []*string {
+	return []*string{&c.mountInfo}
+}
+
+func (c *statusCommand) Run(a *app, args []string) int {
+	// /dev/disk/by-* links are the ones of the running system, not of the root
+	if a.global.root != "" {
+		a.log.Printf("status is not supported with -root")
+		return ExitError
+	}
+
+	p, err := a.load()
+	if err != nil {
+		return a.fail("", err)
+	}
+	mounted, err := mount.ReadMountInfo(a.fs, c.mountInfo)
+	if err != nil {
+		return a.fail("Status", err)
+	}
+	err = mount.WriteMountStatuses(a.stdout, mount.MountStatuses(a.fs, p.entries, mounted))
+	if err != nil {
+		return a.fail("Status", err)
+	}
+	return ExitOK
+}
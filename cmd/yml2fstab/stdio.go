@@ -0,0 +1,47 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+)
+
+// stdio is the path that reads stdin as an input and writes stdout as an output.
+const stdio = "-"
+
+// open opens the input at path, stdin for -. Stdin is read at most once, so only one input
+// of a command can be -.
+func (a *app) open(path string) (io.ReadCloser, error) {
+	if path != stdio {
+		return a.fs.Open(path)
+	}
+	if a.stdinRead {
+		return nil, errors.New("stdin is already read, only one input can be -")
+	}
+	a.stdinRead = true
+	return ioutil.NopCloser(a.stdin), nil
+}
+
+// readFile returns the content of the input at path, stdin for -.
+func (a *app) readFile(path string) ([]byte, error) {
+	r, err := a.open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+// inputName is the name of the configuration input in messages and the fstab header.
+func (a *app) inputName() string {
+	if a.global.in == stdio {
+		return "stdin"
+	}
+	return a.global.in
+}
+
+// isStdout reports whether the output path writes stdout, an empty path does for the
+// commands that print by default.
+func isStdout(path string) bool {
+	return path == "" || path == stdio
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+)
+
+type validateCommand struct{}
+
+func (*validateCommand) Name() string { return "validate" }
+
+func (*validateCommand) Synopsis() string { return "Check the configuration without writing anything" }
+
+func (*validateCommand) Usage() string {
+	return `Usage: yml2fstab validate
+
+Parses the configuration, validates every entry and renders it in the -format output,
+so a configuration that validates also generates. Exits non-zero when the configuration is
+not valid YAML or has a malformed field (4), breaks a rule (5) or cannot be rendered (6).`
+}
+
+func (*validateCommand) SetFlags(fs *flag.FlagSet) {}
+
+func (*validateCommand) Run(a *app, args []string) int {
+	p, err := a.load()
+	if err == nil {
+		_, err = a.render(p, nil)
+	}
+	if err != nil {
+		return a.fail("", err)
+	}
+	fmt.Fprintf(a.stdout, "%s: %d entries valid\n", a.global.in, len(p.configs)+len(p.autofs)+len(p.absent))
+	return ExitOK
+}
@@ -0,0 +1,35 @@
+package config
+
+const (
+	// AutomountAutofs moves an entry from fstab into the autofs maps.
+	AutomountAutofs = "autofs"
+
+	AutofsMapDirect   = "direct"
+	AutofsMapIndirect = "indirect"
+)
+
+func (c *Config) IsAutofs() bool {
+	return c.Automount == AutomountAutofs
+}
+
+// GetAutofsMap returns the map kind of an autofs entry, direct unless set otherwise.
+func (c *Config) GetAutofsMap() string {
+	if c.AutofsMap == "" {
+		return AutofsMapDirect
+	}
+	return c.AutofsMap
+}
+
+// SplitAutofsConfigs separates the entries rendered as fstab lines from the autofs entries.
+func SplitAutofsConfigs(configs []*Config) ([]*Config, []*Config) {
+	fstab := make([]*Config, 0)
+	autofs := make([]*Config, 0)
+	for _, c := range configs {
+		if c.IsAutofs() {
+			autofs = append(autofs, c)
+		} else {
+			fstab = append(fstab, c)
+		}
+	}
+	return fstab, autofs
+}
@@ -0,0 +1,391 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+type Config struct {
+	Source               string            `json:"source"`
+	Mount                string            `json:"mount"`
+	Type                 string            `json:"type"`
+	Export               string            `json:"export,omitempty"`
+	Options              []string          `json:"options"`
+	BackupOperation      int               `json:"dump"`
+	FileSystemCheckOrder int               `json:"pass"`
+	Swap                 *SwapConfig       `json:"swap,omitempty"`
+	Automount            string            `json:"automount,omitempty"`
+	AutofsMap            string            `json:"autofs_map,omitempty"`
+	Encryption           *EncryptionConfig `json:"encryption,omitempty"`
+	Capacity             string            `json:"capacity,omitempty"`
+	AccessModes          []string          `json:"access_modes,omitempty"`
+	Comment              string            `json:"comment,omitempty"`
+	State                string            `json:"state,omitempty"`
+}
+
+func (c *Config) SetBackupOperation(s int) {
+	c.BackupOperation = s
+
+}
+
+func (c *Config) SetFileSystemCheckOrder(s int) {
+	c.BackupOperation = s
+
+}
+
+func (c *Config) SetSource(s string) {
+	c.Source = s
+
+}
+
+func (c *Config) SetMount(m string) {
+	c.Mount = m
+}
+
+func (c *Config) SetType(t string) {
+	c.Type = t
+}
+
+func (c *Config) SetExport(export string) {
+	c.Export = export
+}
+
+func (c *Config) AddOption(opt string) {
+	if c.Options == nil {
+		c.Options = make([]string, 0)
+	}
+	c.Options = append(c.Options, opt)
+}
+
+func (c *Config) AddOptions(opts []string) {
+	if c.Options == nil {
+		c.Options = make([]string, 0)
+	}
+	for _, v := range opts {
+		c.Options = append(c.Options, v)
+	}
+}
+
+func (c *Config) GetOptions() []string {
+	return c.Options
+}
+
+func (c *Config) GenerateOptionString() string {
+	if len(c.Options) == 0 {
+		return "defaults"
+	}
+	return BuildStringFromSlice(c.Options)
+}
+
+func BuildStringFromSlice(arr []string) string {
+	if len(arr) == 0 {
+		return ""
+	}
+	opt := strings.Join(arr, ",")
+	return opt
+}
+
+func (c *Config) GetMountPoint() string {
+	return c.Mount
+}
+
+func (c *Config) GetBackupOperation() int {
+	return c.BackupOperation
+}
+
+func (c *Config) GetFileSystemCheckOrder() int {
+	return c.FileSystemCheckOrder
+}
+
+func (c *Config) GetFileSystemType() string {
+	return c.Type
+}
+
+func (c *Config) GetMountDevice() string {
+	return GetMountDevice(c.Source, c.Export)
+}
+
+func (c *Config) IsMountValid() bool {
+	return c.Mount != ""
+}
+
+func (c *Config) IsFileSystemTypeValid() bool {
+	return c.Type != ""
+}
+
+func (c *Config) IsValid() bool {
+	return c.IsFileSystemTypeValid() && c.IsMountValid()
+
+}
+
+func GetMountDevice(source string, export string) string {
+	var mountDevice string
+	if CheckHost(source) {
+		mountDevice = fmt.Sprintf("%s:%s", source, export)
+	} else if CheckIPAddress(source) {
+		mountDevice = fmt.Sprintf("%s:%s", source, export)
+	} else {
+		mountDevice = source
+	}
+
+	return mountDevice
+}
+
+func NewConfigFromMapData(source string, m map[string]interface{}) (*Config, error) {
+	//parse mount field
+	if m["mount"] == nil {
+		return nil, missingFieldError(source, "mount")
+	}
+	mount, ok := m["mount"].(string)
+	if !ok {
+		return nil, invalidTypeError(source, "mount", "string")
+	}
+	//parse export field
+	export := ""
+	if m["export"] != nil {
+		e := m["export"]
+		exp, ok := e.(string)
+		if !ok {
+			return nil, invalidTypeError(source, "export", "string")
+		} else {
+			export = exp
+		}
+	}
+
+	//parse type field
+	if m["type"] == nil {
+		return nil, missingFieldError(source, "type")
+	}
+	fsType, ok := m["type"].(string)
+	if !ok {
+		return nil, invalidTypeError(source, "type", "string")
+	}
+
+	//parse automount fields
+	automount := ""
+	if m["automount"] != nil {
+		a, ok := m["automount"].(string)
+		if !ok {
+			return nil, invalidTypeError(source, "automount", "string")
+		}
+		automount = a
+	}
+	autofsMap := ""
+	if m["autofs-map"] != nil {
+		a, ok := m["autofs-map"].(string)
+		if !ok {
+			return nil, invalidTypeError(source, "autofs-map", "string")
+		}
+		autofsMap = a
+	}
+
+	//parse comment field
+	comment := ""
+	if m["comment"] != nil {
+		c, ok := m["comment"].(string)
+		if !ok {
+			return nil, invalidTypeError(source, "comment", "string")
+		}
+		comment = c
+	}
+
+	//parse state field
+	state := ""
+	if m["state"] != nil {
+		s, ok := m["state"].(string)
+		if !ok {
+			return nil, invalidTypeError(source, "state", "string")
+		}
+		state = s
+	}
+
+	//parse persistent volume fields
+	capacity := ""
+	if m["capacity"] != nil {
+		c, ok := m["capacity"].(string)
+		if !ok {
+			return nil, invalidTypeError(source, "capacity", "string")
+		}
+		capacity = c
+	}
+	accessModes := make([]string, 0)
+	if m["access-modes"] != nil {
+		modes, ok := m["access-modes"].([]interface{})
+		if !ok {
+			return nil, invalidTypeError(source, "access-modes", "list of strings")
+		}
+		for _, v := range modes {
+			mode, ok := v.(string)
+			if !ok {
+				return nil, invalidTypeError(source, "access-modes", "string")
+			}
+			accessModes = append(accessModes, mode)
+		}
+	}
+
+	//parse encryption block, the entry is then mounted from its mapper device
+	var encryption *EncryptionConfig
+	if m["encryption"] != nil {
+		e, ok := m["encryption"].(map[string]interface{})
+		if !ok {
+			return nil, invalidTypeError(source, "encryption", "map")
+		}
+		enc, err := NewEncryptionConfigFromMapData(MapperNameFromSource(source), e)
+		if err != nil {
+			return nil, err
+		}
+		encryption = enc
+		source = enc.GetMapperDevice()
+	}
+
+	//parse options
+	options := make([]string, 0)
+	if m["options"] != nil {
+		op := m["options"]
+		switch reflect.TypeOf(op).Kind() {
+		case reflect.Slice:
+			opts, ok := op.([]interface{})
+			if !ok {
+				return nil, invalidTypeError(source, "options", "list of strings")
+			}
+			for _, v := range opts {
+				opt, ok := v.(string)
+				if !ok {
+					return nil, invalidTypeError(source, "options", "string")
+				}
+				options = append(options, opt)
+			}
+		default:
+			return nil, invalidTypeError(source, "options", "list of strings")
+		}
+	}
+
+	// create new configuration
+	conf := NewConfigWithOptions(
+		WithConfigSource(source),
+		WithConfigMount(mount),
+		WithConfigFSType(fsType),
+		WithConfigExport(export),
+		WithConfigBackupOperation(0),
+		WithConfigFileSystemCheckOrder(0),
+		WithConfigOptions(options),
+		WithConfigAutomount(automount),
+		WithConfigAutofsMap(autofsMap),
+		WithConfigEncryption(encryption),
+		WithConfigCapacity(capacity),
+		WithConfigAccessModes(accessModes),
+		WithConfigComment(comment),
+		WithConfigState(state),
+	)
+	conf.FileSystemCheckOrder = DefaultFileSystemPass(conf)
+	return conf, nil
+}
+
+func NewConfigs(mm map[string]interface{}) ([]*Config, error) {
+	var configs []*Config
+	for k, v := range mm {
+		r, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, invalidTypeError(k, "", "map")
+		}
+		conf, err := NewConfigFromMapData(k, r)
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, conf)
+	}
+	return configs, nil
+}
+
+type ConfigOption func(*Config)
+
+func NewConfigWithOptions(options ...ConfigOption) *Config {
+	config := &Config{}
+	for _, opt := range options {
+		opt(config)
+	}
+	return config
+}
+
+func WithConfigSource(source string) ConfigOption {
+	return func(config *Config) {
+		config.Source = source
+	}
+}
+func WithConfigBackupOperation(bo int) ConfigOption {
+	return func(config *Config) {
+		config.BackupOperation = bo
+	}
+}
+func WithConfigFileSystemCheckOrder(fsCheckOrder int) ConfigOption {
+
+	return func(config *Config) {
+		config.FileSystemCheckOrder = fsCheckOrder
+	}
+}
+
+func WithConfigExport(export string) ConfigOption {
+	return func(config *Config) {
+		config.Export = export
+	}
+}
+
+func WithConfigMount(mount string) ConfigOption {
+	return func(config *Config) {
+		config.Mount = mount
+	}
+}
+func WithConfigFSType(fsType string) ConfigOption {
+	return func(config *Config) {
+		config.Type = fsType
+	}
+}
+
+func WithConfigOptions(options []string) ConfigOption {
+	return func(config *Config) {
+		config.Options = options
+	}
+}
+
+func WithConfigAutomount(automount string) ConfigOption {
+	return func(config *Config) {
+		config.Automount = automount
+	}
+}
+
+func WithConfigAutofsMap(autofsMap string) ConfigOption {
+	return func(config *Config) {
+		config.AutofsMap = autofsMap
+	}
+}
+
+func WithConfigEncryption(encryption *EncryptionConfig) ConfigOption {
+	return func(config *Config) {
+		config.Encryption = encryption
+	}
+}
+
+func WithConfigCapacity(capacity string) ConfigOption {
+	return func(config *Config) {
+		config.Capacity = capacity
+	}
+}
+
+func WithConfigAccessModes(accessModes []string) ConfigOption {
+	return func(config *Config) {
+		config.AccessModes = accessModes
+	}
+}
+
+func WithConfigComment(comment string) ConfigOption {
+	return func(config *Config) {
+		config.Comment = comment
+	}
+}
+
+func WithConfigState(state string) ConfigOption {
+	return func(config *Config) {
+		config.State = state
+	}
+}
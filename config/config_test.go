@@ -1,4 +1,4 @@
-package main
+package config
 
 import (
 	"github.com/stretchr/testify/assert"
@@ -156,6 +156,20 @@ func TestNewConfigFromMap(t *testing.T) {
 		assert.Equal(t, cnf.GetMountDevice(), "192.168.4.6:/var/nfs/home")
 		assert.Equal(t, len(cnf.GetOptions()), 2)
 	})
+
+	t.Run("default pass of the filesystem type", func(t *testing.T) {
+		data := make(map[string]interface{})
+		data["mount"] = "/"
+		data["type"] = "ext4"
+		cnf, err := NewConfigFromMapData("/dev/sda2", data)
+		assert.NoError(t, err)
+		assert.Equal(t, cnf.GetFileSystemCheckOrder(), 1)
+
+		data["mount"] = "/var/lib/postgresql"
+		cnf, err = NewConfigFromMapData("/dev/sdb1", data)
+		assert.NoError(t, err)
+		assert.Equal(t, cnf.GetFileSystemCheckOrder(), 2)
+	})
 }
 
 func TestNewConfigs(t *testing.T) {
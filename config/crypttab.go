@@ -0,0 +1,97 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const (
+	// MapperDir holds the device-mapper nodes of opened LUKS volumes.
+	MapperDir = "/dev/mapper/"
+	// CrypttabKeyNone asks for the passphrase at boot.
+	CrypttabKeyNone = "none"
+)
+
+type EncryptionConfig struct {
+	// device-mapper name, the volume is opened as /dev/mapper/<name>
+	Name string `json:"name"`
+	// LUKS device, a path or a UUID= style tag
+	Device string `json:"device"`
+	// key file, or none for a passphrase
+	Key     string   `json:"key"`
+	Options []string `json:"options,omitempty"`
+}
+
+func (e *EncryptionConfig) GetMapperDevice() string {
+	return MapperDir + e.Name
+}
+
+func (e *EncryptionConfig) GenerateOptionString() string {
+	if len(e.Options) == 0 {
+		return "luks"
+	}
+	return BuildStringFromSlice(e.Options)
+}
+
+// GenerateCrypttabEntryString renders the crypttab(5) line "name device key options".
+func (e *EncryptionConfig) GenerateCrypttabEntryString() string {
+	return fmt.Sprintf("%s %s %s %s", e.Name, e.Device, e.Key, e.GenerateOptionString())
+}
+
+// MapperNameFromSource accepts either a bare mapper name or a /dev/mapper/ path.
+func MapperNameFromSource(source string) string {
+	return strings.TrimPrefix(source, MapperDir)
+}
+
+func CheckMapperNameValid(name string) bool {
+	return name != "" && !strings.ContainsAny(name, "/ \t")
+}
+
+func NewEncryptionConfigFromMapData(name string, m map[string]interface{}) (*EncryptionConfig, error) {
+	//parse device field
+	if m["device"] == nil {
+		return nil, missingFieldError(name, "encryption.device")
+	}
+	device, ok := m["device"].(string)
+	if !ok {
+		return nil, invalidTypeError(name, "encryption.device", "string")
+	}
+
+	//parse key field, a passphrase is asked when missing
+	key := CrypttabKeyNone
+	if m["key"] != nil {
+		k, ok := m["key"].(string)
+		if !ok {
+			return nil, invalidTypeError(name, "encryption.key", "string")
+		}
+		key = k
+	}
+
+	//parse options
+	options := make([]string, 0)
+	if m["options"] != nil {
+		op := m["options"]
+		if reflect.TypeOf(op).Kind() != reflect.Slice {
+			return nil, invalidTypeError(name, "encryption.options", "list of strings")
+		}
+		opts, ok := op.([]interface{})
+		if !ok {
+			return nil, invalidTypeError(name, "encryption.options", "list of strings")
+		}
+		for _, v := range opts {
+			opt, ok := v.(string)
+			if !ok {
+				return nil, invalidTypeError(name, "encryption.options", "string")
+			}
+			options = append(options, opt)
+		}
+	}
+
+	return &EncryptionConfig{
+		Name:    name,
+		Device:  device,
+		Key:     key,
+		Options: options,
+	}, nil
+}
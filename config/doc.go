@@ -0,0 +1,6 @@
+// Package config loads the yml2fstab YAML document into Config values and holds the
+// filesystem type registry used to render and validate them.
+//
+// ReadConfigFromXmlFile and ParseConfigs return a *ParseError when the document is
+// malformed or a field has the wrong format, read errors are returned unwrapped.
+package config
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrMissingField is wrapped by the errors of a required field that is not set.
+	ErrMissingField = errors.New("missing field")
+	// ErrInvalidType is wrapped by the errors of a field holding the wrong YAML type.
+	ErrInvalidType = errors.New("invalid field type")
+)
+
+// FieldError is a field of an entry that cannot be parsed, Err is ErrMissingField or ErrInvalidType.
+type FieldError struct {
+	// Entry is the source or mapper name keying the entry, empty for the top level keys
+	Entry string
+	Field string
+	// Expected is the YAML type required by an ErrInvalidType field
+	Expected string
+	Err      error
+}
+
+func (e *FieldError) Error() string {
+	var msg string
+	switch {
+	case errors.Is(e.Err, ErrMissingField):
+		msg = fmt.Sprintf("%s field not found", e.Field)
+	case e.Field == "":
+		msg = fmt.Sprintf("invalid format. Require %s", e.Expected)
+	default:
+		msg = fmt.Sprintf("invalid format for %s field. Require %s", e.Field, e.Expected)
+	}
+	if e.Entry == "" {
+		return msg
+	}
+	return e.Entry + ": " + msg
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+func missingFieldError(entry string, field string) error {
+	return &FieldError{Entry: entry, Field: field, Err: ErrMissingField}
+}
+
+func invalidTypeError(entry string, field string, expected string) error {
+	return &FieldError{Entry: entry, Field: field, Expected: expected, Err: ErrInvalidType}
+}
+
+// ParseError is returned by the loaders when a YAML document cannot be turned into configs.
+// Path is empty when the document did not come from a file.
+type ParseError struct {
+	Path string
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	if e.Path == "" {
+		return e.Err.Error()
+	}
+	return e.Path + ": " + e.Err.Error()
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,31 @@
+package config
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestFieldError(t *testing.T) {
+	t.Run("missing type", func(t *testing.T) {
+		_, err := NewConfigFromMapData("/dev/sda1", map[string]interface{}{"mount": "/boot"})
+		assert.True(t, errors.Is(err, ErrMissingField))
+		var ferr *FieldError
+		assert.True(t, errors.As(err, &ferr))
+		assert.Equal(t, "type", ferr.Field)
+		assert.Equal(t, "/dev/sda1: type field not found", err.Error())
+	})
+
+	t.Run("invalid type", func(t *testing.T) {
+		_, err := NewConfigFromMapData("/dev/sda1", map[string]interface{}{"mount": "/boot", "type": "xfs", "options": 1})
+		assert.True(t, errors.Is(err, ErrInvalidType))
+		assert.False(t, errors.Is(err, ErrMissingField))
+	})
+
+	t.Run("parse error", func(t *testing.T) {
+		_, err := ParseConfigs([]byte("fstab:\n  /dev/sda1:\n    type: xfs\n"))
+		var perr *ParseError
+		assert.True(t, errors.As(err, &perr))
+		assert.True(t, errors.Is(err, ErrMissingField))
+	})
+}
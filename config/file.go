@@ -0,0 +1,192 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"gopkg.in/yaml.v3"
+
+	"tienbm90/yml2fstab/vfs"
+)
+
+// ReadConfigFromXmlFile reads the YAML file at path of fsys and parses it with ParseConfigs.
+// Read errors are returned as they are, parse errors as a *ParseError naming the file.
+func ReadConfigFromXmlFile(fsys vfs.FS, path string) ([]*Config, error) {
+	// read yml file
+	ymlFile, err := fsys.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer ymlFile.Close()
+
+	configs, err := ReadConfigs(ymlFile)
+	var perr *ParseError
+	if errors.As(err, &perr) {
+		perr.Path = path
+	}
+	return configs, err
+}
+
+// ReadConfigs reads a YAML document from r until EOF and parses it with ParseConfigs.
+func ReadConfigs(r io.Reader) ([]*Config, error) {
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return ParseConfigs(content)
+}
+
+// ParseConfigs parses the fstab and swap sections of a YAML document into sorted configs.
+// Every error is a *ParseError.
+func ParseConfigs(content []byte) ([]*Config, error) {
+	data := make(map[string]interface{})
+
+	err := yaml.Unmarshal(content, &data)
+
+	if err != nil {
+		return nil, &ParseError{Err: err}
+	}
+
+	// get data and put into configs
+	fstabContent := data["fstab"]
+	swapContent := data["swap"]
+	if fstabContent == nil && swapContent == nil {
+		return nil, &ParseError{Err: missingFieldError("", "fstab")}
+	}
+
+	configs := make([]*Config, 0)
+	if fstabContent != nil {
+		fconfig, ok := fstabContent.(map[string]interface{})
+		if !ok {
+			return nil, &ParseError{Err: invalidTypeError("", "fstab", "map")}
+		}
+		fstabConfigs, err := NewConfigs(fconfig)
+		if err != nil {
+			return nil, &ParseError{Err: err}
+		}
+		configs = append(configs, fstabConfigs...)
+	}
+
+	if swapContent != nil {
+		sconfig, ok := swapContent.(map[string]interface{})
+		if !ok {
+			return nil, &ParseError{Err: invalidTypeError("", "swap", "map")}
+		}
+		swapConfigs, err := NewSwapConfigs(sconfig)
+		if err != nil {
+			return nil, &ParseError{Err: err}
+		}
+		configs = append(configs, swapConfigs...)
+	}
+
+	SortConfigs(configs)
+	return configs, nil
+}
+
+type yamlEncryption struct {
+	Device  string   `yaml:"device"`
+	Key     string   `yaml:"key,omitempty"`
+	Options []string `yaml:"options,omitempty"`
+}
+
+type yamlConfig struct {
+	Mount       string          `yaml:"mount"`
+	Export      string          `yaml:"export,omitempty"`
+	Type        string          `yaml:"type"`
+	Options     []string        `yaml:"options,omitempty"`
+	Automount   string          `yaml:"automount,omitempty"`
+	AutofsMap   string          `yaml:"autofs-map,omitempty"`
+	Encryption  *yamlEncryption `yaml:"encryption,omitempty"`
+	Capacity    string          `yaml:"capacity,omitempty"`
+	AccessModes []string        `yaml:"access-modes,omitempty"`
+	Comment     string          `yaml:"comment,omitempty"`
+	State       string          `yaml:"state,omitempty"`
+}
+
+type yamlSwapConfig struct {
+	Priority *int        `yaml:"priority,omitempty"`
+	Discard  interface{} `yaml:"discard,omitempty"`
+	NoFail   bool        `yaml:"nofail,omitempty"`
+	Comment  string      `yaml:"comment,omitempty"`
+	State    string      `yaml:"state,omitempty"`
+}
+
+func newYamlConfig(c *Config) (string, *yamlConfig) {
+	source := c.Source
+	entry := &yamlConfig{
+		Mount:       c.Mount,
+		Export:      c.Export,
+		Type:        c.Type,
+		Options:     c.Options,
+		Automount:   c.Automount,
+		AutofsMap:   c.AutofsMap,
+		Capacity:    c.Capacity,
+		AccessModes: c.AccessModes,
+		Comment:     c.Comment,
+		State:       c.State,
+	}
+	// the key of an encrypted entry is its mapper name
+	if c.Encryption != nil {
+		source = c.Encryption.Name
+		entry.Encryption = &yamlEncryption{Device: c.Encryption.Device, Options: c.Encryption.Options}
+		if c.Encryption.Key != CrypttabKeyNone {
+			entry.Encryption.Key = c.Encryption.Key
+		}
+	}
+	return source, entry
+}
+
+func newYamlSwapConfig(c *Config) *yamlSwapConfig {
+	entry := &yamlSwapConfig{Comment: c.Comment, State: c.State}
+	if c.Swap == nil {
+		return entry
+	}
+	if c.Swap.Priority != SwapPriorityDefault {
+		priority := c.Swap.Priority
+		entry.Priority = &priority
+	}
+	switch c.Swap.Discard {
+	case "":
+	case "all":
+		entry.Discard = true
+	default:
+		entry.Discard = c.Swap.Discard
+	}
+	entry.NoFail = c.Swap.NoFail
+	return entry
+}
+
+// MarshalConfigs writes configs as the YAML document read by ParseConfigs. Entries are
+// keyed by source, two configs with the same source cannot be written.
+func MarshalConfigs(configs []*Config) ([]byte, error) {
+	fstabEntries := make(map[string]*yamlConfig)
+	swapEntries := make(map[string]*yamlSwapConfig)
+	for _, c := range configs {
+		if c.IsSwap() {
+			if _, ok := swapEntries[c.Source]; ok {
+				return nil, fmt.Errorf("swap %s is defined more than once", c.Source)
+			}
+			swapEntries[c.Source] = newYamlSwapConfig(c)
+			continue
+		}
+		source, entry := newYamlConfig(c)
+		if _, ok := fstabEntries[source]; ok {
+			return nil, fmt.Errorf("source %s is defined more than once", source)
+		}
+		fstabEntries[source] = entry
+	}
+
+	data := make(map[string]interface{})
+	if len(fstabEntries) > 0 {
+		data["fstab"] = fstabEntries
+	}
+	if len(swapEntries) > 0 {
+		data["swap"] = swapEntries
+	}
+	if len(data) == 0 {
+		return nil, errors.New("no entry to write")
+	}
+	return yaml.Marshal(data)
+}
@@ -0,0 +1,123 @@
+package config
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestParseConfigs(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		content := []byte(`
+fstab:
+  /dev/sda1:
+    mount: /boot
+    type: xfs
+  192.168.4.5:
+    mount: /home
+    export: /var/nfs/home
+    type: nfs
+swap:
+  /dev/sda3:
+    priority: 10
+`)
+		configs, err := ParseConfigs(content)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, len(configs))
+
+		devices := make([]string, 0)
+		for _, cnf := range configs {
+			devices = append(devices, cnf.GetMountDevice())
+		}
+		assert.Contains(t, devices, "/dev/sda1")
+		assert.Contains(t, devices, "192.168.4.5:/var/nfs/home")
+		assert.Contains(t, devices, "/dev/sda3")
+	})
+
+	t.Run("parse errors", func(t *testing.T) {
+		invalid := []string{
+			"fstab: [",
+			"other: {}",
+			"fstab: /dev/sda1",
+			"swap: /dev/sda3",
+			"fstab:\n  /dev/sda1:\n    type: xfs\n",
+		}
+		for _, content := range invalid {
+			_, err := ParseConfigs([]byte(content))
+			var perr *ParseError
+			assert.True(t, errors.As(err, &perr), content)
+			assert.Equal(t, "", perr.Path)
+		}
+	})
+}
+
+func TestReadConfigs(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		configs, err := ReadConfigs(strings.NewReader("fstab:\n  /dev/sda1:\n    mount: /boot\n    type: xfs\n"))
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(configs))
+		assert.Equal(t, "/boot", configs[0].GetMountPoint())
+	})
+
+	t.Run("read error", func(t *testing.T) {
+		cause := errors.New("broken pipe")
+		_, err := ReadConfigs(iotest.ErrReader(cause))
+		assert.Equal(t, cause, err)
+	})
+}
+
+func TestParseError(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		cause := errors.New("fstab key not found")
+		assert.Equal(t, "fstab key not found", (&ParseError{Err: cause}).Error())
+
+		err := &ParseError{Path: "input.yml", Err: cause}
+		assert.Equal(t, "input.yml: fstab key not found", err.Error())
+		assert.True(t, errors.Is(err, cause))
+	})
+}
+
+func TestMarshalConfigs(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		content := []byte(`
+fstab:
+  192.168.4.5:
+    mount: /home
+    export: /var/nfs/home
+    type: nfs
+    options: [noexec, nosuid]
+    comment: shared homes
+  data:
+    mount: /data
+    type: ext4
+    encryption:
+      device: UUID=0a3407de-014b-458b-b5c1-848e92a327a3
+      key: /etc/keys/data.key
+swap:
+  /dev/sda3:
+    priority: 10
+    discard: true
+  /swapfile:
+`)
+		configs, err := ParseConfigs(content)
+		assert.NoError(t, err)
+
+		out, err := MarshalConfigs(configs)
+		assert.NoError(t, err)
+		parsed, err := ParseConfigs(out)
+		assert.NoError(t, err)
+		assert.Equal(t, configs, parsed)
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		_, err := MarshalConfigs(nil)
+		assert.Error(t, err)
+
+		boot := NewConfigWithOptions(WithConfigSource("/dev/sda1"), WithConfigMount("/boot"), WithConfigFSType("xfs"))
+		again := NewConfigWithOptions(WithConfigSource("/dev/sda1"), WithConfigMount("/mnt"), WithConfigFSType("xfs"))
+		_, err = MarshalConfigs([]*Config{boot, again})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,34 @@
+package config
+
+import (
+	"sort"
+	"strings"
+)
+
+// orderKey returns the path an entry is ordered by. Mount points sort parents
+// before children, and a swap file sorts by its own path so it always follows
+// the filesystem that holds it. Swap devices go last.
+func orderKey(c *Config) (string, bool) {
+	if c.IsSwapFile() {
+		return c.Source, true
+	}
+	if strings.HasPrefix(c.Mount, "/") {
+		return c.Mount, true
+	}
+	return "", false
+}
+
+// SortConfigs orders configs in the sequence their fstab lines must be written.
+func SortConfigs(configs []*Config) {
+	sort.SliceStable(configs, func(i, j int) bool {
+		ki, oki := orderKey(configs[i])
+		kj, okj := orderKey(configs[j])
+		if oki != okj {
+			return oki
+		}
+		if ki != kj {
+			return ki < kj
+		}
+		return configs[i].Source < configs[j].Source
+	})
+}
@@ -0,0 +1,25 @@
+package config
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSortConfigs(t *testing.T) {
+	t.Run("swap file after the filesystem holding it", func(t *testing.T) {
+		configs := []*Config{
+			NewConfigWithOptions(WithConfigSource("/dev/sda3"), WithConfigMount(SwapMountPoint), WithConfigFSType("swap")),
+			NewConfigWithOptions(WithConfigSource("/var/swapfile"), WithConfigMount(SwapMountPoint), WithConfigFSType("swap")),
+			NewConfigWithOptions(WithConfigSource("/dev/sdb1"), WithConfigMount("/var/lib/postgresql"), WithConfigFSType("ext4")),
+			NewConfigWithOptions(WithConfigSource("/dev/sdb2"), WithConfigMount("/var"), WithConfigFSType("xfs")),
+			NewConfigWithOptions(WithConfigSource("/dev/sda2"), WithConfigMount("/"), WithConfigFSType("ext4")),
+		}
+		SortConfigs(configs)
+
+		sources := make([]string, 0)
+		for _, c := range configs {
+			sources = append(sources, c.Source)
+		}
+		assert.Equal(t, []string{"/dev/sda2", "/dev/sdb2", "/dev/sdb1", "/var/swapfile", "/dev/sda3"}, sources)
+	})
+}
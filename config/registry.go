@@ -0,0 +1,297 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"tienbm90/yml2fstab/vfs"
+)
+
+// FileSystemType describes how entries of one filesystem type are rendered and validated.
+type FileSystemType interface {
+	// Name is the type as written in the third fstab column.
+	Name() string
+	// RenderSource returns the first fstab column for the entry.
+	RenderSource(c *Config) (string, error)
+	// OptionCatalog lists the type specific mount options, without values.
+	OptionCatalog() []string
+	// DefaultPass returns the fsck pass number of the entry.
+	DefaultPass(c *Config) int
+	// IsNetwork reports whether the filesystem needs the network to be mounted.
+	IsNetwork() bool
+	// Validate returns an error if the entry cannot be rendered for this type.
+	Validate(c *Config) error
+}
+
+type FileSystemTypeValidator func(c *Config) error
+
+type FileSystemTypeSourceRenderer func(c *Config) string
+
+type BasicFileSystemType struct {
+	name           string
+	network        bool
+	pass           int
+	catalog        []string
+	sourceRenderer FileSystemTypeSourceRenderer
+	validators     []FileSystemTypeValidator
+}
+
+func (t *BasicFileSystemType) Name() string {
+	return t.name
+}
+
+func (t *BasicFileSystemType) RenderSource(c *Config) (string, error) {
+	if t.sourceRenderer != nil {
+		return t.sourceRenderer(c), nil
+	}
+	if t.network {
+		return c.GetMountDevice(), nil
+	}
+	return renderVerbatimSource(c), nil
+}
+
+func (t *BasicFileSystemType) OptionCatalog() []string {
+	return t.catalog
+}
+
+// DefaultPass checks the root filesystem first when the type is checked by fsck at all.
+func (t *BasicFileSystemType) DefaultPass(c *Config) int {
+	if t.pass > 0 && c.GetMountPoint() == "/" {
+		return 1
+	}
+	return t.pass
+}
+
+func (t *BasicFileSystemType) IsNetwork() bool {
+	return t.network
+}
+
+func (t *BasicFileSystemType) Validate(c *Config) error {
+	for _, validate := range t.validators {
+		if err := validate(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type FileSystemTypeOption func(*BasicFileSystemType)
+
+func NewFileSystemType(name string, options ...FileSystemTypeOption) *BasicFileSystemType {
+	t := &BasicFileSystemType{name: name}
+	for _, opt := range options {
+		opt(t)
+	}
+	return t
+}
+
+func WithNetwork() FileSystemTypeOption {
+	return func(t *BasicFileSystemType) {
+		t.network = true
+	}
+}
+
+func WithDefaultPass(pass int) FileSystemTypeOption {
+	return func(t *BasicFileSystemType) {
+		t.pass = pass
+	}
+}
+
+func WithOptionCatalog(catalog []string) FileSystemTypeOption {
+	return func(t *BasicFileSystemType) {
+		t.catalog = catalog
+	}
+}
+
+func WithSourceRenderer(renderer FileSystemTypeSourceRenderer) FileSystemTypeOption {
+	return func(t *BasicFileSystemType) {
+		t.sourceRenderer = renderer
+	}
+}
+
+func WithValidator(validator FileSystemTypeValidator) FileSystemTypeOption {
+	return func(t *BasicFileSystemType) {
+		t.validators = append(t.validators, validator)
+	}
+}
+
+type FileSystemRegistry struct {
+	mu    sync.RWMutex
+	types map[string]FileSystemType
+}
+
+func NewFileSystemRegistry() *FileSystemRegistry {
+	return &FileSystemRegistry{types: make(map[string]FileSystemType)}
+}
+
+// Register adds t to the registry, replacing any type with the same name.
+func (r *FileSystemRegistry) Register(t FileSystemType) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.types[t.Name()] = t
+}
+
+func (r *FileSystemRegistry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.types, name)
+}
+
+func (r *FileSystemRegistry) Lookup(name string) (FileSystemType, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	t, ok := r.types[name]
+	return t, ok
+}
+
+func (r *FileSystemRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.types))
+	for name := range r.types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadProcFileSystems registers every type listed in a /proc/filesystems formatted file of
+// fsys that is not registered yet. Types seeded this way have no option catalog or validation.
+func (r *FileSystemRegistry) LoadProcFileSystems(fsys vfs.FS, path string) error {
+	file, err := fsys.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// each line is an optional "nodev" flag followed by the type name
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		name := fields[len(fields)-1]
+		if _, ok := r.Lookup(name); ok {
+			continue
+		}
+		r.Register(NewFileSystemType(name))
+	}
+	return scanner.Err()
+}
+
+var DefaultFileSystemRegistry = NewFileSystemRegistry()
+
+func RegisterFileSystemType(t FileSystemType) {
+	DefaultFileSystemRegistry.Register(t)
+}
+
+func LookupFileSystemType(name string) (FileSystemType, bool) {
+	return DefaultFileSystemRegistry.Lookup(name)
+}
+
+// RenderMountDevice returns the first fstab column for c, using its registered type when there is one.
+func RenderMountDevice(c *Config) (string, error) {
+	if t, ok := LookupFileSystemType(c.GetFileSystemType()); ok {
+		return t.RenderSource(c)
+	}
+	return c.GetMountDevice(), nil
+}
+
+// DefaultFileSystemPass returns the fsck pass number of c's registered type, 0 if the type is unknown.
+func DefaultFileSystemPass(c *Config) int {
+	if t, ok := LookupFileSystemType(c.GetFileSystemType()); ok {
+		return t.DefaultPass(c)
+	}
+	return 0
+}
+
+func IsNetworkFileSystem(fsType string) bool {
+	if t, ok := LookupFileSystemType(fsType); ok {
+		return t.IsNetwork()
+	}
+	return false
+}
+
+var (
+	extOptions = []string{"acl", "noacl", "barrier", "nobarrier", "data", "discard", "nodiscard",
+		"errors", "journal_checksum", "user_xattr", "nouser_xattr", "commit", "noload"}
+	xfsOptions = []string{"allocsize", "attr2", "noattr2", "discard", "nodiscard", "inode32",
+		"inode64", "logbufs", "logbsize", "noquota", "uquota", "gquota", "pquota", "wsync"}
+	btrfsOptions = []string{"compress", "compress-force", "discard", "nodiscard", "space_cache",
+		"ssd", "nossd", "subvol", "subvolid", "autodefrag", "noautodefrag"}
+	nfsOptions = []string{"vers", "nfsvers", "proto", "port", "rsize", "wsize", "timeo", "retrans",
+		"hard", "soft", "intr", "nointr", "sec", "lookupcache", "ac", "noac", "actimeo", "nolock", "bg", "fg"}
+	cifsOptions = []string{"username", "password", "credentials", "domain", "uid", "gid",
+		"file_mode", "dir_mode", "vers", "sec", "iocharset", "seal", "guest"}
+	fatOptions = []string{"uid", "gid", "umask", "dmask", "fmask", "codepage", "iocharset",
+		"shortname", "utf8", "flush"}
+	swapOptions   = []string{"pri", "discard"}
+	devptsOptions = []string{"uid", "gid", "mode", "ptmxmode", "newinstance"}
+	tmpfsOptions  = []string{"size", "nr_blocks", "nr_inodes", "mode", "uid", "gid", "huge", "mpol"}
+	isoOptions    = []string{"norock", "nojoliet", "check", "uid", "gid", "map", "mode", "unhide", "session", "sbsector"}
+)
+
+// validateNetworkSource requires a host and an absolute export for NFS style sources.
+func validateNetworkSource(c *Config) error {
+	if !CheckHost(c.Source) && !CheckIPAddress(c.Source) {
+		return fmt.Errorf("invalid server %s for %s entry", c.Source, c.GetFileSystemType())
+	}
+	if !strings.HasPrefix(c.Export, "/") {
+		return fmt.Errorf("export of %s must be an absolute path", c.Source)
+	}
+	return nil
+}
+
+func validateCifsSource(c *Config) error {
+	if !CheckHost(c.Source) && !CheckIPAddress(c.Source) {
+		return fmt.Errorf("invalid server %s for cifs entry", c.Source)
+	}
+	if strings.Trim(c.Export, "/") == "" {
+		return errors.New("cifs entry requires the share name in export")
+	}
+	return nil
+}
+
+// renderVerbatimSource keeps the source of local and pseudo filesystems as written, so tmpfs
+// or proc never gain the host:export form of network sources.
+func renderVerbatimSource(c *Config) string {
+	return c.Source
+}
+
+// renderCifsSource writes the UNC path //server/share expected by mount.cifs.
+func renderCifsSource(c *Config) string {
+	return fmt.Sprintf("//%s/%s", c.Source, strings.TrimPrefix(c.Export, "/"))
+}
+
+func init() {
+	for _, name := range []string{"ext", "ext2", "ext3", "ext4"} {
+		RegisterFileSystemType(NewFileSystemType(name, WithDefaultPass(2), WithOptionCatalog(extOptions)))
+	}
+	RegisterFileSystemType(NewFileSystemType("jfs", WithDefaultPass(2)))
+	RegisterFileSystemType(NewFileSystemType("reiserfs", WithDefaultPass(2)))
+	RegisterFileSystemType(NewFileSystemType("ufs", WithDefaultPass(2)))
+	RegisterFileSystemType(NewFileSystemType("xfs", WithOptionCatalog(xfsOptions)))
+	RegisterFileSystemType(NewFileSystemType("btrfs", WithOptionCatalog(btrfsOptions)))
+	RegisterFileSystemType(NewFileSystemType("swap", WithOptionCatalog(swapOptions), WithValidator(ValidateSwapConfig)))
+	RegisterFileSystemType(NewFileSystemType("iso9660", WithOptionCatalog(isoOptions)))
+	RegisterFileSystemType(NewFileSystemType("udf"))
+	RegisterFileSystemType(NewFileSystemType("vfat", WithDefaultPass(2), WithOptionCatalog(fatOptions)))
+	RegisterFileSystemType(NewFileSystemType("devpts", WithOptionCatalog(devptsOptions)))
+	RegisterFileSystemType(NewFileSystemType("tmpfs", WithOptionCatalog(tmpfsOptions)))
+	for _, name := range []string{"nfs", "nfs4"} {
+		RegisterFileSystemType(NewFileSystemType(name,
+			WithNetwork(),
+			WithOptionCatalog(nfsOptions),
+			WithValidator(validateNetworkSource)))
+	}
+	RegisterFileSystemType(NewFileSystemType("cifs",
+		WithNetwork(),
+		WithOptionCatalog(cifsOptions),
+		WithSourceRenderer(renderCifsSource),
+		WithValidator(validateCifsSource)))
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"syscall"
+	"testing"
+
+	"tienbm90/yml2fstab/vfs"
+)
+
+func TestFileSystemRegistry(t *testing.T) {
+	t.Run("register and lookup", func(t *testing.T) {
+		r := NewFileSystemRegistry()
+		r.Register(NewFileSystemType("zfs"))
+		r.Register(NewFileSystemType("ceph", WithNetwork()))
+
+		fst, ok := r.Lookup("ceph")
+		assert.True(t, ok)
+		assert.True(t, fst.IsNetwork())
+
+		_, ok = r.Lookup("hdfs")
+		assert.False(t, ok)
+		assert.Equal(t, []string{"ceph", "zfs"}, r.Names())
+	})
+
+	t.Run("seed from proc filesystems", func(t *testing.T) {
+		fsys := vfs.NewMemoryFS()
+		assert.NoError(t, fsys.MkdirAll("/proc", 0555))
+		content := "nodev\tsysfs\nnodev\ttmpfs\n\text4\n\tzfs\n"
+		assert.NoError(t, vfs.WriteFile(fsys, "/proc/filesystems", []byte(content), 0444))
+
+		r := NewFileSystemRegistry()
+		r.Register(NewFileSystemType("ext4", WithDefaultPass(2)))
+		assert.NoError(t, r.LoadProcFileSystems(fsys, "/proc/filesystems"))
+		assert.Equal(t, []string{"ext4", "sysfs", "tmpfs", "zfs"}, r.Names())
+
+		// registered types are not replaced by seeded ones
+		fst, _ := r.Lookup("ext4")
+		assert.Equal(t, 2, fst.DefaultPass(NewConfigWithOptions(WithConfigMount("/data"))))
+	})
+
+	t.Run("missing proc filesystems", func(t *testing.T) {
+		err := NewFileSystemRegistry().LoadProcFileSystems(vfs.NewMemoryFS(), "/proc/filesystems")
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("unreadable proc filesystems", func(t *testing.T) {
+		fsys := vfs.NewMemoryFS()
+		assert.NoError(t, vfs.WriteFile(fsys, "/filesystems", []byte("\text4\n"), 0444))
+		fsys.Fail(vfs.OpRead, "/filesystems", syscall.EIO)
+		r := NewFileSystemRegistry()
+		err := r.LoadProcFileSystems(fsys, "/filesystems")
+		assert.True(t, errors.Is(err, syscall.EIO))
+		assert.Empty(t, r.Names())
+	})
+}
+
+func TestBasicFileSystemType(t *testing.T) {
+	t.Run("default pass", func(t *testing.T) {
+		fst := NewFileSystemType("ext4", WithDefaultPass(2))
+		assert.Equal(t, 1, fst.DefaultPass(NewConfigWithOptions(WithConfigMount("/"))))
+		assert.Equal(t, 2, fst.DefaultPass(NewConfigWithOptions(WithConfigMount("/home"))))
+		assert.Equal(t, 0, NewFileSystemType("xfs").DefaultPass(NewConfigWithOptions(WithConfigMount("/"))))
+	})
+
+	t.Run("source renderer and validators", func(t *testing.T) {
+		fst := NewFileSystemType("s3fs",
+			WithSourceRenderer(func(c *Config) string { return "s3fs#" + c.Source }),
+			WithValidator(func(c *Config) error {
+				if c.Export != "" {
+					return errors.New("export not supported")
+				}
+				return nil
+			}))
+		cnf := NewConfigWithOptions(WithConfigSource("bucket"), WithConfigMount("/mnt/s3"))
+		source, err := fst.RenderSource(cnf)
+		assert.NoError(t, err)
+		assert.Equal(t, "s3fs#bucket", source)
+		assert.NoError(t, fst.Validate(cnf))
+
+		cnf.SetExport("/data")
+		assert.Error(t, fst.Validate(cnf))
+	})
+}
+
+func TestRenderMountDevice(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		device := func(c *Config) string {
+			device, err := RenderMountDevice(c)
+			assert.NoError(t, err)
+			return device
+		}
+
+		cifs := NewConfigWithOptions(WithConfigSource("fileserver"), WithConfigExport("/share"), WithConfigFSType("cifs"))
+		assert.Equal(t, "//fileserver/share", device(cifs))
+
+		nfs := NewConfigWithOptions(WithConfigSource("192.168.4.5"), WithConfigExport("/var/nfs/home"), WithConfigFSType("nfs"))
+		assert.Equal(t, "192.168.4.5:/var/nfs/home", device(nfs))
+
+		for _, name := range []string{"tmpfs", "devpts", "ext4"} {
+			local := NewConfigWithOptions(WithConfigSource(name), WithConfigMount("/mnt"), WithConfigFSType(name))
+			assert.Equal(t, name, device(local))
+		}
+
+		unknown := NewConfigWithOptions(WithConfigSource("/dev/sda1"), WithConfigFSType("hdfs"))
+		assert.Equal(t, "/dev/sda1", device(unknown))
+	})
+}
@@ -0,0 +1,41 @@
+package config
+
+// States of an entry, present only manages its fstab line.
+const (
+	StatePresent   = "present"
+	StateMounted   = "mounted"
+	StateUnmounted = "unmounted"
+	StateAbsent    = "absent"
+)
+
+var States = []string{StatePresent, StateMounted, StateUnmounted, StateAbsent}
+
+func CheckStateValid(state string) bool {
+	for _, s := range States {
+		if state == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Config) GetState() string {
+	if c.State == "" {
+		return StatePresent
+	}
+	return c.State
+}
+
+// SplitAbsentConfigs separates the entries that must not be written from the others.
+func SplitAbsentConfigs(configs []*Config) ([]*Config, []*Config) {
+	present := make([]*Config, 0)
+	absent := make([]*Config, 0)
+	for _, c := range configs {
+		if c.GetState() == StateAbsent {
+			absent = append(absent, c)
+		} else {
+			present = append(present, c)
+		}
+	}
+	return present, absent
+}
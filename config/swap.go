@@ -0,0 +1,225 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// SwapMountPoint is written in the mount point column of swap entries.
+	SwapMountPoint = "swap"
+
+	// SwapPriorityDefault lets the kernel pick the priority.
+	SwapPriorityDefault = -1
+	// SwapPriorityMax is the highest priority accepted by swapon(2).
+	SwapPriorityMax = 32767
+)
+
+// SwapDiscardPolicies are the values accepted by the discard swap option.
+var SwapDiscardPolicies = []string{"once", "pages"}
+
+// DeviceTags are the fstab(5) tags that can be used in place of a device path.
+var DeviceTags = []string{"UUID=", "LABEL=", "PARTUUID=", "PARTLABEL="}
+
+type SwapConfig struct {
+	// swap priority, SwapPriorityDefault lets the kernel decide
+	Priority int `json:"priority"`
+	// "" disables discard, "all" discards everything, "once" or "pages" restrict it
+	Discard string `json:"discard,omitempty"`
+	// do not report errors if the device or file does not exist
+	NoFail bool `json:"nofail"`
+}
+
+func (s *SwapConfig) GetOptions() []string {
+	options := make([]string, 0)
+	if s.Priority != SwapPriorityDefault {
+		options = append(options, fmt.Sprintf("pri=%d", s.Priority))
+	}
+	switch s.Discard {
+	case "":
+	case "all":
+		options = append(options, "discard")
+	default:
+		options = append(options, fmt.Sprintf("discard=%s", s.Discard))
+	}
+	if s.NoFail {
+		options = append(options, "nofail")
+	}
+	return options
+}
+
+func (c *Config) IsSwap() bool {
+	return c.Type == "swap"
+}
+
+// IsSwapFile reports whether the swap source is a regular file rather than a block device.
+func (c *Config) IsSwapFile() bool {
+	return c.IsSwap() && IsSwapFilePath(c.Source)
+}
+
+func IsSwapFilePath(source string) bool {
+	return strings.HasPrefix(source, "/") && !strings.HasPrefix(source, "/dev/")
+}
+
+func IsDeviceTag(source string) bool {
+	for _, tag := range DeviceTags {
+		if strings.HasPrefix(source, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func CheckSwapPriorityValid(priority int) bool {
+	return priority >= SwapPriorityDefault && priority <= SwapPriorityMax
+}
+
+func CheckSwapDiscardValid(discard string) bool {
+	if discard == "" || discard == "all" {
+		return true
+	}
+	for _, policy := range SwapDiscardPolicies {
+		if discard == policy {
+			return true
+		}
+	}
+	return false
+}
+
+func ValidateSwapConfig(c *Config) error {
+	if c.Source == "" {
+		return errors.New("swap device not found")
+	}
+	if !strings.HasPrefix(c.Source, "/") && !IsDeviceTag(c.Source) {
+		return fmt.Errorf("swap file %s must be an absolute path", c.Source)
+	}
+	if c.FileSystemCheckOrder != 0 {
+		return fmt.Errorf("swap %s must not have a pass number", c.Source)
+	}
+	if c.Swap == nil {
+		return nil
+	}
+	if !CheckSwapPriorityValid(c.Swap.Priority) {
+		return fmt.Errorf("swap %s priority %d out of range [%d, %d]", c.Source, c.Swap.Priority, SwapPriorityDefault, SwapPriorityMax)
+	}
+	if !CheckSwapDiscardValid(c.Swap.Discard) {
+		return fmt.Errorf("swap %s has invalid discard policy %s", c.Source, c.Swap.Discard)
+	}
+	return nil
+}
+
+func NewSwapConfigFromMapData(source string, m map[string]interface{}) (*Config, error) {
+	swap := &SwapConfig{Priority: SwapPriorityDefault}
+
+	//parse priority field
+	if m["priority"] != nil {
+		priority, ok := m["priority"].(int)
+		if !ok {
+			return nil, invalidTypeError(source, "priority", "integer")
+		}
+		swap.Priority = priority
+	}
+
+	//parse discard field
+	if m["discard"] != nil {
+		switch discard := m["discard"].(type) {
+		case bool:
+			if discard {
+				swap.Discard = "all"
+			}
+		case string:
+			swap.Discard = discard
+		default:
+			return nil, invalidTypeError(source, "discard", "boolean or string")
+		}
+	}
+
+	//parse nofail field
+	if m["nofail"] != nil {
+		nofail, ok := m["nofail"].(bool)
+		if !ok {
+			return nil, invalidTypeError(source, "nofail", "boolean")
+		}
+		swap.NoFail = nofail
+	}
+
+	//parse comment field
+	comment := ""
+	if m["comment"] != nil {
+		c, ok := m["comment"].(string)
+		if !ok {
+			return nil, invalidTypeError(source, "comment", "string")
+		}
+		comment = c
+	}
+
+	//parse state field, swap is only present or absent
+	state := ""
+	if m["state"] != nil {
+		s, ok := m["state"].(string)
+		if !ok {
+			return nil, invalidTypeError(source, "state", "string")
+		}
+		state = s
+	}
+
+	//swap is never checked by fsck
+	if m["pass"] != nil {
+		return nil, fmt.Errorf("swap %s must not have a pass number", source)
+	}
+
+	conf := NewConfigWithOptions(
+		WithConfigSource(source),
+		WithConfigMount(SwapMountPoint),
+		WithConfigFSType("swap"),
+		WithConfigBackupOperation(0),
+		WithConfigFileSystemCheckOrder(0),
+		WithConfigOptions(swap.GetOptions()),
+		WithConfigSwap(swap),
+		WithConfigComment(comment),
+		WithConfigState(state),
+	)
+	if err := ValidateSwapConfig(conf); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+func NewSwapConfigs(mm map[string]interface{}) ([]*Config, error) {
+	var configs []*Config
+	for k, v := range mm {
+		r, ok := v.(map[string]interface{})
+		if !ok {
+			if v != nil {
+				return nil, invalidTypeError(k, "", "map")
+			}
+			//a bare device or file without settings
+			r = make(map[string]interface{})
+		}
+		conf, err := NewSwapConfigFromMapData(k, r)
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, conf)
+	}
+	return configs, nil
+}
+
+func WithConfigSwap(swap *SwapConfig) ConfigOption {
+	return func(config *Config) {
+		config.Swap = swap
+	}
+}
+
+// DeviceTagToPath turns a UUID=, LABEL=, PARTUUID= or PARTLABEL= tag into its udev symlink.
+func DeviceTagToPath(device string) string {
+	for _, tag := range DeviceTags {
+		if strings.HasPrefix(device, tag) {
+			dir := "by-" + strings.ToLower(strings.TrimSuffix(tag, "="))
+			return filepath.Join("/dev/disk", dir, strings.TrimPrefix(device, tag))
+		}
+	}
+	return device
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNewSwapConfigFromMapData(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		data := make(map[string]interface{})
+		data["priority"] = 10
+		data["discard"] = "once"
+		data["nofail"] = true
+
+		cnf, err := NewSwapConfigFromMapData("/dev/sda3", data)
+		assert.NoError(t, err)
+		assert.Equal(t, cnf.GetMountPoint(), SwapMountPoint)
+		assert.Equal(t, cnf.GetFileSystemType(), "swap")
+		assert.Equal(t, cnf.GenerateOptionString(), "pri=10,discard=once,nofail")
+		assert.False(t, cnf.IsSwapFile())
+	})
+
+	t.Run("swap file with defaults", func(t *testing.T) {
+		cnf, err := NewSwapConfigFromMapData("/var/swapfile", make(map[string]interface{}))
+		assert.NoError(t, err)
+		assert.True(t, cnf.IsSwapFile())
+		assert.Equal(t, cnf.GenerateOptionString(), "defaults")
+	})
+
+	t.Run("discard everything", func(t *testing.T) {
+		data := make(map[string]interface{})
+		data["discard"] = true
+		cnf, err := NewSwapConfigFromMapData("UUID=0a3407de-014b-458b-b5c1-848e92a327a3", data)
+		assert.NoError(t, err)
+		assert.Equal(t, cnf.GenerateOptionString(), "discard")
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		invalid := []struct {
+			Source string
+			Data   map[string]interface{}
+		}{
+			{Source: "swapfile", Data: map[string]interface{}{}},
+			{Source: "/dev/sda3", Data: map[string]interface{}{"priority": 32768}},
+			{Source: "/dev/sda3", Data: map[string]interface{}{"priority": -2}},
+			{Source: "/dev/sda3", Data: map[string]interface{}{"priority": "high"}},
+			{Source: "/dev/sda3", Data: map[string]interface{}{"discard": "always"}},
+			{Source: "/dev/sda3", Data: map[string]interface{}{"nofail": "yes"}},
+			{Source: "/dev/sda3", Data: map[string]interface{}{"pass": 2}},
+			{Source: "/dev/sda3", Data: map[string]interface{}{"pass": 0}},
+		}
+		for _, d := range invalid {
+			_, err := NewSwapConfigFromMapData(d.Source, d.Data)
+			assert.Error(t, err, d.Source)
+		}
+	})
+}
+
+func TestNewSwapConfigs(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mm := make(map[string]interface{})
+		mm["/dev/sda3"] = map[string]interface{}{"priority": 5}
+		mm["/swapfile"] = nil
+
+		cnfs, err := NewSwapConfigs(mm)
+		assert.NoError(t, err)
+		assert.Equal(t, len(cnfs), 2)
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		mm := make(map[string]interface{})
+		mm["/dev/sda3"] = "priority"
+		_, err := NewSwapConfigs(mm)
+		assert.Error(t, err)
+	})
+}
@@ -1,4 +1,4 @@
-package main
+package config
 
 import (
 	"net"
@@ -10,12 +10,8 @@ func CheckFileSystemTypeValid(fs string) bool {
 	if fs == "" {
 		return false
 	}
-	for _, fst := range FileSystemTypes {
-		if fs == fst {
-			return true
-		}
-	}
-	return false
+	_, ok := LookupFileSystemType(fs)
+	return ok
 }
 
 func CheckMountPointValid(mp string) bool {
@@ -1,4 +1,4 @@
-package main
+package config
 
 import (
 	"github.com/stretchr/testify/assert"
@@ -75,4 +75,4 @@ func TestCheckHost(t *testing.T) {
 		assert.False(t, CheckHost("9gag_com"))
 		assert.False(t, CheckHost("1"))
 	})
-}
\ No newline at end of file
+}
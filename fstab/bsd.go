@@ -0,0 +1,153 @@
+package fstab
+
+import (
+	"fmt"
+	"strings"
+
+	"tienbm90/yml2fstab/config"
+)
+
+const (
+	FormatBSD = "bsd"
+
+	// BSDSwapMountPoint is the mount point column of BSD swap entries.
+	BSDSwapMountPoint = "none"
+)
+
+// BSDFileSystemTypes maps Linux types to their FreeBSD names.
+var BSDFileSystemTypes = map[string]string{
+	"ufs":     "ufs",
+	"ext":     "ext2fs",
+	"ext2":    "ext2fs",
+	"ext3":    "ext2fs",
+	"ext4":    "ext2fs",
+	"nfs":     "nfs",
+	"nfs4":    "nfs",
+	"vfat":    "msdosfs",
+	"tmpfs":   "tmpfs",
+	"iso9660": "cd9660",
+	"udf":     "udf",
+	"cifs":    "smbfs",
+	"swap":    "swap",
+}
+
+// BSDOptions maps Linux options to their BSD spelling.
+var BSDOptions = map[string]string{
+	"nofail":  "failok",
+	"_netdev": "late",
+}
+
+// BSDUnsupportedOptions are Linux mount options mount(8) on BSD does not understand.
+var BSDUnsupportedOptions = []string{"user", "users", "owner", "group", "errors", "relatime",
+	"strictatime", "lazytime", "nodiratime", "dirsync", "iversion", "nouser", "comment"}
+
+// BSDDialect renders FreeBSD style fstab(5) lines. Every line has an explicit rw, ro or sw
+// option, swap is mounted on "none" and fields are tab separated.
+type BSDDialect struct{}
+
+func (BSDDialect) Name() string {
+	return FormatBSD
+}
+
+func checkBSDOption(opt string) error {
+	key := OptionKey(opt)
+	if strings.HasPrefix(key, SystemdOptionPrefix) {
+		return fmt.Errorf("option %s is systemd specific", opt)
+	}
+	for _, unsupported := range BSDUnsupportedOptions {
+		if key == unsupported {
+			return fmt.Errorf("option %s is not supported", opt)
+		}
+	}
+	return nil
+}
+
+func convertBSDSwapOptions(opts []string) ([]string, error) {
+	options := []string{"sw"}
+	for _, opt := range opts {
+		switch opt {
+		case "discard", "discard=once":
+			options = append(options, "trimonce")
+		case "sw":
+		default:
+			if OptionKey(opt) == "pri" || OptionKey(opt) == "discard" {
+				return nil, fmt.Errorf("swap option %s is not supported", opt)
+			}
+			options = append(options, opt)
+		}
+	}
+	return options, nil
+}
+
+func convertBSDOptions(opts []string) ([]string, error) {
+	mode := ""
+	options := make([]string, 0)
+	for _, opt := range opts {
+		switch opt {
+		case "rw", "ro":
+			if mode != "" && mode != opt {
+				return nil, fmt.Errorf("options rw and ro are exclusive")
+			}
+			mode = opt
+		case "auto":
+		default:
+			options = append(options, opt)
+		}
+	}
+	// rw or ro is mandatory and comes first
+	if mode == "" {
+		mode = "rw"
+	}
+	return append([]string{mode}, options...), nil
+}
+
+func (BSDDialect) ConvertLine(line *FstabLine) (*FstabLine, error) {
+	fsType, ok := BSDFileSystemTypes[line.FileSystemType]
+	if !ok {
+		return nil, fmt.Errorf("filesystem type %s has no BSD equivalent", line.FileSystemType)
+	}
+
+	opts := make([]string, 0)
+	for _, opt := range SplitOptions(line.Options) {
+		if err := checkBSDOption(opt); err != nil {
+			return nil, err
+		}
+		if bsd, ok := BSDOptions[opt]; ok {
+			opt = bsd
+		}
+		opts = append(opts, opt)
+	}
+	if line.FileSystemType == "nfs4" {
+		opts = append(opts, "nfsv4")
+	}
+
+	mountPoint := line.MountPoint
+	var options []string
+	var err error
+	if fsType == "swap" {
+		mountPoint = BSDSwapMountPoint
+		options, err = convertBSDSwapOptions(opts)
+	} else {
+		options, err = convertBSDOptions(opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return NewFstabLineWithOptions(
+		WithDevice(line.Device),
+		WithMountPoint(mountPoint),
+		WithFileSystemType(fsType),
+		WithOptions(config.BuildStringFromSlice(options)),
+		WithBackupOperation(line.BackupOperation),
+		WithFileSystemCheckOrder(line.FileSystemCheckOrder),
+	), nil
+}
+
+func (BSDDialect) FormatLine(line *FstabLine) string {
+	return strings.Join(FstabLineFields(line), "\t")
+}
+
+func init() {
+	RegisterDialect(BSDDialect{})
+}
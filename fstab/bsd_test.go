@@ -0,0 +1,79 @@
+package fstab
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+
+	"tienbm90/yml2fstab/config"
+)
+
+func TestBSDDialect(t *testing.T) {
+	d, ok := LookupDialect(FormatBSD)
+	assert.True(t, ok)
+
+	t.Run("success", func(t *testing.T) {
+		data := []struct {
+			Line     *FstabLine
+			Expected string
+		}{
+			{
+				Line:     NewFstabEntry("/dev/ada0p2", "/", "ufs", "defaults", 1, 1),
+				Expected: "/dev/ada0p2\t/\tufs\trw\t1\t1",
+			},
+			{
+				Line:     NewFstabEntry("/dev/ada0p4", "/usr/local", "ufs", "noatime,ro", 0, 2),
+				Expected: "/dev/ada0p4\t/usr/local\tufs\tro,noatime\t0\t2",
+			},
+			{
+				Line:     NewFstabEntry("/dev/ada0p1", "/boot/efi", "vfat", "defaults", 0, 2),
+				Expected: "/dev/ada0p1\t/boot/efi\tmsdosfs\trw\t0\t2",
+			},
+			{
+				Line:     NewFstabEntry("192.168.4.5:/var/nfs/home", "/home", "nfs4", "noexec,nosuid,_netdev,nofail", 0, 0),
+				Expected: "192.168.4.5:/var/nfs/home\t/home\tnfs\trw,noexec,nosuid,late,failok,nfsv4\t0\t0",
+			},
+			{
+				Line:     NewFstabEntry("/dev/ada0p3", config.SwapMountPoint, "swap", "discard", 0, 0),
+				Expected: "/dev/ada0p3\tnone\tswap\tsw,trimonce\t0\t0",
+			},
+			{
+				Line:     NewFstabEntry("tmpfs", "/tmp", "tmpfs", "mode=1777,size=1g", 0, 0),
+				Expected: "tmpfs\t/tmp\ttmpfs\trw,mode=1777,size=1g\t0\t0",
+			},
+		}
+		for _, d := range data {
+			line, err := BSDDialect{}.ConvertLine(d.Line)
+			assert.NoError(t, err)
+			assert.Equal(t, d.Expected, BSDDialect{}.FormatLine(line))
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		invalid := []*FstabLine{
+			NewFstabEntry("/dev/sda1", "/boot", "xfs", "defaults", 0, 0),
+			NewFstabEntry("/dev/sdb1", "/data", "ufs", "rw,ro", 0, 2),
+			NewFstabEntry("/dev/sdb1", "/data", "ufs", "x-systemd.automount", 0, 2),
+			NewFstabEntry("/dev/sdb1", "/data", "ufs", "errors=remount-ro", 0, 2),
+			NewFstabEntry("/dev/sdb1", "/data", "ufs", "user", 0, 2),
+			NewFstabEntry("/dev/ada0p3", config.SwapMountPoint, "swap", "pri=10", 0, 0),
+			NewFstabEntry("/dev/ada0p3", config.SwapMountPoint, "swap", "discard=pages", 0, 0),
+		}
+		for _, line := range invalid {
+			_, err := d.ConvertLine(line)
+			assert.Error(t, err, line.GenerateFstabEntryString())
+		}
+	})
+
+	t.Run("convert lines", func(t *testing.T) {
+		entries := []*FstabLine{
+			NewFstabEntry("/dev/ada0p2", "/", "ufs", "defaults", 0, 1),
+			NewFstabEntry("/dev/sda1", "/boot", "xfs", "defaults", 0, 0),
+		}
+		_, err := ConvertFstabLines(d, entries)
+		assert.Error(t, err)
+
+		lines, err := ConvertFstabLines(d, entries[:1])
+		assert.NoError(t, err)
+		assert.Equal(t, "rw", lines[0].Options)
+	})
+}
@@ -0,0 +1,134 @@
+package fstab
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+
+	"tienbm90/yml2fstab/config"
+)
+
+// DriftStatus classifies an entry of the live fstab against the rendered one.
+type DriftStatus string
+
+const (
+	DriftInSync   DriftStatus = "in-sync"
+	DriftMissing  DriftStatus = "missing"
+	DriftExtra    DriftStatus = "extra"
+	DriftModified DriftStatus = "modified"
+)
+
+// Exit codes of -check, the convention of monitoring plugins.
+const (
+	CheckExitInSync = 0
+	CheckExitDrift  = 1
+	CheckExitError  = 2
+)
+
+// FieldDrift is a field whose live value differs from the rendered one.
+type FieldDrift struct {
+	Field    string `json:"field"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+type EntryDrift struct {
+	// Key is the mount point, or the device for swap
+	Key      string       `json:"key"`
+	Status   DriftStatus  `json:"status"`
+	Expected *FstabLine   `json:"expected,omitempty"`
+	Actual   *FstabLine   `json:"actual,omitempty"`
+	Fields   []FieldDrift `json:"fields,omitempty"`
+}
+
+type DriftReport struct {
+	Path    string              `json:"path"`
+	InSync  bool                `json:"in_sync"`
+	Summary map[DriftStatus]int `json:"summary"`
+	Entries []EntryDrift        `json:"entries"`
+}
+
+// normalizeOptions makes option strings comparable, the order and "defaults" do not matter.
+func normalizeOptions(options string) string {
+	opts := SplitOptions(options)
+	sort.Strings(opts)
+	return config.BuildStringFromSlice(opts)
+}
+
+// CompareFstabLines returns the fields of actual that differ from expected.
+func CompareFstabLines(expected, actual *FstabLine) []FieldDrift {
+	fields := make([]FieldDrift, 0)
+	compare := func(field, e, a string) {
+		if e != a {
+			fields = append(fields, FieldDrift{Field: field, Expected: e, Actual: a})
+		}
+	}
+	compare("device", expected.Device, actual.Device)
+	compare("mount_point", expected.MountPoint, actual.MountPoint)
+	compare("type", expected.FileSystemType, actual.FileSystemType)
+	if normalizeOptions(expected.Options) != normalizeOptions(actual.Options) {
+		compare("options", expected.Options, actual.Options)
+	}
+	compare("dump", strconv.Itoa(expected.BackupOperation), strconv.Itoa(actual.BackupOperation))
+	compare("pass", strconv.Itoa(expected.FileSystemCheckOrder), strconv.Itoa(actual.FileSystemCheckOrder))
+	return fields
+}
+
+// CheckDrift compares the live entries with the rendered ones. Entries sharing a key are
+// matched in order. Rendered entries come first in the report, then the unmanaged ones.
+func CheckDrift(path string, expected, actual []*FstabLine) *DriftReport {
+	report := &DriftReport{
+		Path:    path,
+		Summary: make(map[DriftStatus]int),
+		Entries: make([]EntryDrift, 0),
+	}
+
+	live := make(map[string][]int)
+	for i, ent := range actual {
+		key := ent.Key()
+		live[key] = append(live[key], i)
+	}
+	matched := make(map[int]bool)
+
+	for _, exp := range expected {
+		key := exp.Key()
+		drift := EntryDrift{Key: key, Status: DriftMissing, Expected: exp}
+		if candidates := live[key]; len(candidates) > 0 {
+			i := candidates[0]
+			live[key] = candidates[1:]
+			matched[i] = true
+			drift.Actual = actual[i]
+			drift.Status = DriftInSync
+			if fields := CompareFstabLines(exp, actual[i]); len(fields) > 0 {
+				drift.Status = DriftModified
+				drift.Fields = fields
+			}
+		}
+		report.Entries = append(report.Entries, drift)
+	}
+	for i, ent := range actual {
+		if !matched[i] {
+			report.Entries = append(report.Entries, EntryDrift{Key: ent.Key(), Status: DriftExtra, Actual: ent})
+		}
+	}
+
+	for _, drift := range report.Entries {
+		report.Summary[drift.Status]++
+	}
+	report.InSync = report.Summary[DriftInSync] == len(report.Entries)
+	return report
+}
+
+func (r *DriftReport) ExitCode() int {
+	if r.InSync {
+		return CheckExitInSync
+	}
+	return CheckExitDrift
+}
+
+func WriteDriftReport(w io.Writer, r *DriftReport) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
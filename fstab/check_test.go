@@ -0,0 +1,89 @@
+package fstab
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCheckDrift(t *testing.T) {
+	expected := []*FstabLine{
+		NewFstabEntry("/dev/sda2", "/", "ext4", "defaults", 0, 1),
+		NewFstabEntry("192.168.4.5:/var/nfs/home", "/home", "nfs", "noexec,nosuid", 0, 0),
+		NewFstabEntry("/dev/sdb1", "/var/lib/postgresql", "ext4", "defaults", 0, 2),
+		NewFstabEntry("/dev/sda3", "swap", "swap", "pri=10", 0, 0),
+	}
+
+	t.Run("in sync", func(t *testing.T) {
+		live := []*FstabLine{
+			NewFstabEntry("/dev/sda2", "/", "ext4", "", 0, 1),
+			NewFstabEntry("192.168.4.5:/var/nfs/home", "/home", "nfs", "nosuid,noexec", 0, 0),
+			NewFstabEntry("/dev/sdb1", "/var/lib/postgresql", "ext4", "defaults", 0, 2),
+			NewFstabEntry("/dev/sda3", "swap", "swap", "pri=10", 0, 0),
+		}
+		report := CheckDrift("/etc/fstab", expected, live)
+		assert.True(t, report.InSync)
+		assert.Equal(t, CheckExitInSync, report.ExitCode())
+		assert.Equal(t, map[DriftStatus]int{DriftInSync: 4}, report.Summary)
+	})
+
+	t.Run("drift", func(t *testing.T) {
+		live := []*FstabLine{
+			NewFstabEntry("/dev/sda2", "/", "ext4", "defaults", 0, 1),
+			NewFstabEntry("192.168.4.5:/var/nfs/home", "/home", "nfs4", "noexec", 0, 0),
+			NewFstabEntry("/dev/sda3", "none", "swap", "pri=10", 0, 0),
+			NewFstabEntry("/dev/sdc1", "/scratch", "xfs", "defaults", 0, 0),
+		}
+		report := CheckDrift("/etc/fstab", expected, live)
+		assert.False(t, report.InSync)
+		assert.Equal(t, CheckExitDrift, report.ExitCode())
+		assert.Equal(t, map[DriftStatus]int{DriftInSync: 1, DriftModified: 2, DriftMissing: 1, DriftExtra: 1}, report.Summary)
+
+		statuses := make([]DriftStatus, 0)
+		for _, e := range report.Entries {
+			statuses = append(statuses, e.Status)
+		}
+		assert.Equal(t, []DriftStatus{DriftInSync, DriftModified, DriftMissing, DriftModified, DriftExtra}, statuses)
+		assert.Equal(t, []FieldDrift{
+			{Field: "type", Expected: "nfs", Actual: "nfs4"},
+			{Field: "options", Expected: "noexec,nosuid", Actual: "noexec"},
+		}, report.Entries[1].Fields)
+		assert.Equal(t, "/var/lib/postgresql", report.Entries[2].Key)
+		assert.Nil(t, report.Entries[2].Actual)
+		assert.Equal(t, "/dev/sda3", report.Entries[3].Key)
+		assert.Equal(t, []FieldDrift{{Field: "mount_point", Expected: "swap", Actual: "none"}}, report.Entries[3].Fields)
+		assert.Nil(t, report.Entries[4].Expected)
+	})
+
+	t.Run("duplicate mount points", func(t *testing.T) {
+		stacked := []*FstabLine{
+			NewFstabEntry("/dev/sdb1", "/data", "ext4", "defaults", 0, 2),
+			NewFstabEntry("/dev/sdb3", "/data", "ext4", "defaults", 0, 2),
+		}
+		report := CheckDrift("/etc/fstab", stacked, stacked[:1])
+		assert.Equal(t, map[DriftStatus]int{DriftInSync: 1, DriftMissing: 1}, report.Summary)
+	})
+
+	t.Run("json report", func(t *testing.T) {
+		report := CheckDrift("/etc/fstab", expected[:1], nil)
+		var b bytes.Buffer
+		assert.NoError(t, WriteDriftReport(&b, report))
+
+		decoded := struct {
+			Path    string         `json:"path"`
+			InSync  bool           `json:"in_sync"`
+			Summary map[string]int `json:"summary"`
+			Entries []struct {
+				Key    string `json:"key"`
+				Status string `json:"status"`
+			} `json:"entries"`
+		}{}
+		assert.NoError(t, json.Unmarshal(b.Bytes(), &decoded))
+		assert.Equal(t, "/etc/fstab", decoded.Path)
+		assert.False(t, decoded.InSync)
+		assert.Equal(t, map[string]int{"missing": 1}, decoded.Summary)
+		assert.Equal(t, "/", decoded.Entries[0].Key)
+		assert.Equal(t, "missing", decoded.Entries[0].Status)
+	})
+}
@@ -0,0 +1,218 @@
+package fstab
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"tienbm90/yml2fstab/config"
+)
+
+// Fstab is an ordered set of fstab lines, at most one per Key.
+type Fstab struct {
+	Lines []*FstabLine
+}
+
+func NewFstab(lines ...*FstabLine) *Fstab {
+	return &Fstab{Lines: append(make([]*FstabLine, 0, len(lines)), lines...)}
+}
+
+func (f *Fstab) Len() int {
+	return len(f.Lines)
+}
+
+func (f *Fstab) indexOf(key string) int {
+	for i, ent := range f.Lines {
+		if ent.Key() == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// FindByMountPoint returns the first line mounted at mountPoint.
+func (f *Fstab) FindByMountPoint(mountPoint string) (*FstabLine, bool) {
+	for _, ent := range f.Lines {
+		if ent.MountPoint == mountPoint {
+			return ent, true
+		}
+	}
+	return nil, false
+}
+
+// FindByDevice returns the first line of device.
+func (f *Fstab) FindByDevice(device string) (*FstabLine, bool) {
+	for _, ent := range f.Lines {
+		if ent.Device == device {
+			return ent, true
+		}
+	}
+	return nil, false
+}
+
+// Add appends ent, it fails if a line with the same key exists.
+func (f *Fstab) Add(ent *FstabLine) error {
+	if f.indexOf(ent.Key()) >= 0 {
+		return fmt.Errorf("%s is already in the fstab", ent.Key())
+	}
+	f.Lines = append(f.Lines, ent)
+	return nil
+}
+
+// Replace puts ent in place of the line with the same key, it fails if there is none.
+func (f *Fstab) Replace(ent *FstabLine) error {
+	i := f.indexOf(ent.Key())
+	if i < 0 {
+		return fmt.Errorf("%s is not in the fstab", ent.Key())
+	}
+	f.Lines[i] = ent
+	return nil
+}
+
+// Remove removes the line of key, the mount point or the device of a swap line,
+// and reports whether there was one.
+func (f *Fstab) Remove(key string) bool {
+	i := f.indexOf(key)
+	if i < 0 {
+		return false
+	}
+	f.Lines = append(f.Lines[:i], f.Lines[i+1:]...)
+	return true
+}
+
+// fstabLineOrderKey mirrors the config order, parents before children, swap
+// files after the filesystem that holds them and swap devices last.
+func fstabLineOrderKey(ent *FstabLine) (string, bool) {
+	if ent.FileSystemType == "swap" {
+		return ent.Device, config.IsSwapFilePath(ent.Device)
+	}
+	if strings.HasPrefix(ent.MountPoint, "/") {
+		return ent.MountPoint, true
+	}
+	return "", false
+}
+
+// Sort orders the lines in the sequence they must be mounted.
+func (f *Fstab) Sort() {
+	sort.SliceStable(f.Lines, func(i, j int) bool {
+		ki, oki := fstabLineOrderKey(f.Lines[i])
+		kj, okj := fstabLineOrderKey(f.Lines[j])
+		if oki != okj {
+			return oki
+		}
+		if ki != kj {
+			return ki < kj
+		}
+		return f.Lines[i].Device < f.Lines[j].Device
+	})
+}
+
+// Validate checks every line and that no key is used twice, it returns the first problem.
+func (f *Fstab) Validate() error {
+	seen := make(map[string]bool)
+	for _, ent := range f.Lines {
+		switch {
+		case ent.Device == "":
+			return fmt.Errorf("device not found for %s", ent.MountPoint)
+		case !ent.IsMountPointValid():
+			return fmt.Errorf("invalid mount point %s for %s", ent.MountPoint, ent.Device)
+		case !ent.IsFileSystemTypeValid():
+			return fmt.Errorf("unsupported filesystem type %s for %s", ent.FileSystemType, ent.Device)
+		case !ent.IsBackupOperationValid():
+			return fmt.Errorf("invalid dump %d for %s", ent.BackupOperation, ent.Key())
+		case !ent.IsFileSystemCheckOrderValid():
+			return fmt.Errorf("invalid pass %d for %s", ent.FileSystemCheckOrder, ent.Key())
+		case seen[ent.Key()]:
+			return fmt.Errorf("%s is defined more than once", ent.Key())
+		}
+		seen[ent.Key()] = true
+	}
+	return nil
+}
+
+// FstabChange is a line whose fields differ between two fstabs.
+type FstabChange struct {
+	Key    string       `json:"key"`
+	Old    *FstabLine   `json:"old"`
+	New    *FstabLine   `json:"new"`
+	Fields []FieldDrift `json:"fields"`
+}
+
+// FstabChangeset turns one fstab into another.
+type FstabChangeset struct {
+	Added   []*FstabLine  `json:"added"`
+	Removed []*FstabLine  `json:"removed"`
+	Changed []FstabChange `json:"changed"`
+}
+
+func (c *FstabChangeset) IsEmpty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0 && len(c.Changed) == 0
+}
+
+// Diff returns the changes from f to other, lines are matched by key and options
+// compared regardless of their order.
+func (f *Fstab) Diff(other *Fstab) *FstabChangeset {
+	changes := &FstabChangeset{
+		Added:   make([]*FstabLine, 0),
+		Removed: make([]*FstabLine, 0),
+		Changed: make([]FstabChange, 0),
+	}
+	for _, drift := range CheckDrift("", other.Lines, f.Lines).Entries {
+		switch drift.Status {
+		case DriftMissing:
+			changes.Added = append(changes.Added, drift.Expected)
+		case DriftExtra:
+			changes.Removed = append(changes.Removed, drift.Actual)
+		case DriftModified:
+			changes.Changed = append(changes.Changed, FstabChange{Key: drift.Key, Old: drift.Actual, New: drift.Expected, Fields: drift.Fields})
+		}
+	}
+	return changes
+}
+
+// Marshal writes the lines in the fstab(5) format, a comment goes above its line.
+func (f *Fstab) Marshal() ([]byte, error) {
+	var b strings.Builder
+	for _, ent := range f.Lines {
+		if ent.Device == "" || ent.MountPoint == "" || ent.FileSystemType == "" {
+			return nil, errors.New("device, mount point and type are required to marshal a fstab line")
+		}
+		if ent.Comment != "" {
+			b.WriteString(strings.Join(commentLines(ent.Comment), "\n") + "\n")
+		}
+		line := *ent
+		if line.Options == "" {
+			line.Options = "defaults"
+		}
+		b.WriteString(line.GenerateFstabEntryString() + "\n")
+	}
+	return []byte(b.String()), nil
+}
+
+// Unmarshal replaces the lines with the entries of data. Comment lines right above an
+// entry become its comment, other comments and blank lines are dropped.
+func (f *Fstab) Unmarshal(data []byte) error {
+	lines := make([]*FstabLine, 0)
+	comment := make([]string, 0)
+	for i, line := range strings.Split(string(data), "\n") {
+		ent, err := ParseFstabLine(line)
+		if err != nil {
+			return fmt.Errorf("line %d: %s", i+1, err)
+		}
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case ent != nil:
+			ent.Comment = strings.Join(comment, "\n")
+			lines = append(lines, ent)
+			comment = comment[:0]
+		case strings.HasPrefix(trimmed, "#"):
+			text := strings.TrimPrefix(trimmed, "#")
+			comment = append(comment, strings.TrimPrefix(text, " "))
+		default:
+			comment = comment[:0]
+		}
+	}
+	f.Lines = lines
+	return nil
+}
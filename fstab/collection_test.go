@@ -0,0 +1,172 @@
+package fstab
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func newTestFstab() *Fstab {
+	return NewFstab(
+		NewFstabEntry("/dev/sda1", "/boot", "xfs", "defaults", 0, 2),
+		NewFstabEntry("/dev/sda2", "/", "ext4", "defaults", 0, 1),
+		NewFstabEntry("/dev/sda3", "swap", "swap", "pri=10", 0, 0),
+		NewFstabEntry("/swapfile", "swap", "swap", "defaults", 0, 0),
+		NewFstabEntry("192.168.4.5:/var/nfs/home", "/home", "nfs", "noexec,nosuid", 0, 0),
+	)
+}
+
+func TestFstabQuery(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		f := newTestFstab()
+		ent, ok := f.FindByMountPoint("/home")
+		assert.True(t, ok)
+		assert.Equal(t, "192.168.4.5:/var/nfs/home", ent.Device)
+
+		ent, ok = f.FindByDevice("/dev/sda3")
+		assert.True(t, ok)
+		assert.Equal(t, "pri=10", ent.Options)
+
+		_, ok = f.FindByMountPoint("/data")
+		assert.False(t, ok)
+		_, ok = f.FindByDevice("/dev/sdb1")
+		assert.False(t, ok)
+	})
+}
+
+func TestFstabMutation(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		f := newTestFstab()
+		assert.NoError(t, f.Add(NewFstabEntry("/dev/sdb1", "/data", "ext4", "defaults", 0, 2)))
+		assert.Equal(t, 6, f.Len())
+
+		assert.NoError(t, f.Replace(NewFstabEntry("/dev/sdb2", "/data", "xfs", "noatime", 0, 2)))
+		ent, _ := f.FindByMountPoint("/data")
+		assert.Equal(t, "/dev/sdb2", ent.Device)
+		assert.Equal(t, 6, f.Len())
+
+		assert.True(t, f.Remove("/data"))
+		assert.True(t, f.Remove("/dev/sda3"))
+		assert.False(t, f.Remove("/data"))
+		assert.Equal(t, 4, f.Len())
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		f := newTestFstab()
+		assert.Error(t, f.Add(NewFstabEntry("/dev/sdb1", "/boot", "ext4", "defaults", 0, 2)))
+		assert.Error(t, f.Replace(NewFstabEntry("/dev/sdb1", "/data", "ext4", "defaults", 0, 2)))
+		assert.Equal(t, 5, f.Len())
+	})
+}
+
+func TestFstabSort(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		f := newTestFstab()
+		f.Add(NewFstabEntry("/dev/sdb1", "/home/shared", "ext4", "defaults", 0, 2))
+		f.Sort()
+
+		keys := make([]string, 0)
+		for _, ent := range f.Lines {
+			keys = append(keys, ent.Key())
+		}
+		assert.Equal(t, []string{"/", "/boot", "/home", "/home/shared", "/swapfile", "/dev/sda3"}, keys)
+	})
+}
+
+func TestFstabValidate(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		assert.NoError(t, newTestFstab().Validate())
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		invalid := []*FstabLine{
+			NewFstabEntry("", "/data", "ext4", "defaults", 0, 2),
+			NewFstabEntry("/dev/sdb1", "data", "ext4", "defaults", 0, 2),
+			NewFstabEntry("/dev/sdb1", "/data", "hdfs", "defaults", 0, 2),
+			NewFstabEntry("/dev/sdb1", "/data", "ext4", "defaults", 2, 2),
+			NewFstabEntry("/dev/sdb1", "/data", "ext4", "defaults", 0, 3),
+			NewFstabEntry("/dev/sdb1", "/boot", "ext4", "defaults", 0, 2),
+		}
+		for _, ent := range invalid {
+			f := newTestFstab()
+			f.Lines = append(f.Lines, ent)
+			assert.Error(t, f.Validate(), ent.GenerateFstabEntryString())
+		}
+	})
+}
+
+func TestFstabDiff(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		before := newTestFstab()
+		after := newTestFstab()
+		after.Remove("/swapfile")
+		after.Replace(NewFstabEntry("/dev/sda1", "/boot", "xfs", "noatime", 0, 2))
+		after.Replace(NewFstabEntry("192.168.4.5:/var/nfs/home", "/home", "nfs", "nosuid,noexec", 0, 0))
+		after.Add(NewFstabEntry("/dev/sdb1", "/data", "ext4", "defaults", 0, 2))
+
+		changes := before.Diff(after)
+		assert.False(t, changes.IsEmpty())
+		assert.Equal(t, 1, len(changes.Added))
+		assert.Equal(t, "/data", changes.Added[0].MountPoint)
+		assert.Equal(t, 1, len(changes.Removed))
+		assert.Equal(t, "/swapfile", changes.Removed[0].Device)
+		assert.Equal(t, 1, len(changes.Changed))
+		assert.Equal(t, "/boot", changes.Changed[0].Key)
+		assert.Equal(t, "defaults", changes.Changed[0].Old.Options)
+		assert.Equal(t, "noatime", changes.Changed[0].New.Options)
+		assert.Equal(t, "options", changes.Changed[0].Fields[0].Field)
+
+		assert.True(t, before.Diff(newTestFstab()).IsEmpty())
+	})
+}
+
+func TestFstabMarshal(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		f := newTestFstab()
+		f.Add(NewFstabLineWithOptions(
+			WithDevice("//fileserver/my share"),
+			WithMountPoint("/mnt/my share"),
+			WithFileSystemType("cifs"),
+			WithComment("shared with the office\nread only"),
+		))
+
+		data, err := f.Marshal()
+		assert.NoError(t, err)
+		assert.Contains(t, string(data), "# shared with the office\n# read only\n//fileserver/my\\040share /mnt/my\\040share cifs defaults 0 0\n")
+
+		parsed := NewFstab()
+		assert.NoError(t, parsed.Unmarshal(data))
+		assert.Equal(t, f.Len(), parsed.Len())
+		assert.True(t, f.Diff(parsed).IsEmpty())
+		ent, ok := parsed.FindByMountPoint("/mnt/my share")
+		assert.True(t, ok)
+		assert.Equal(t, "shared with the office\nread only", ent.Comment)
+	})
+
+	t.Run("escaped options round trip", func(t *testing.T) {
+		content := "/dev/sdb1 /data ext4 rw,x-label=my\\040disk 0 2\n"
+		f := NewFstab()
+		assert.NoError(t, f.Unmarshal([]byte(content)))
+		assert.Equal(t, "rw,x-label=my disk", f.Lines[0].Options)
+
+		data, err := f.Marshal()
+		assert.NoError(t, err)
+		assert.Equal(t, content, string(data))
+		assert.Equal(t, f.Lines[0].GenerateFstabEntryString()+"\n", string(data))
+	})
+
+	t.Run("unmarshal drops detached comments", func(t *testing.T) {
+		f := NewFstab()
+		assert.NoError(t, f.Unmarshal([]byte("# /etc/fstab\n\n/dev/sda2 / ext4 defaults 0 1\n#root data\n/dev/sdb1 /data ext4 defaults\n")))
+		assert.Equal(t, 2, f.Len())
+		assert.Equal(t, "", f.Lines[0].Comment)
+		assert.Equal(t, "root data", f.Lines[1].Comment)
+		assert.Equal(t, 0, f.Lines[1].FileSystemCheckOrder)
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		_, err := NewFstab(NewFstabEntry("/dev/sdb1", "", "ext4", "defaults", 0, 2)).Marshal()
+		assert.Error(t, err)
+
+		assert.Error(t, NewFstab().Unmarshal([]byte("/dev/sdb1 /data\n")))
+	})
+}
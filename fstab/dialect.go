@@ -0,0 +1,101 @@
+package fstab
+
+import (
+	"sort"
+	"strings"
+)
+
+const (
+	FormatFstab = "fstab"
+
+	// SystemdOptionPrefix starts the options only read by systemd-fstab-generator.
+	SystemdOptionPrefix = "x-systemd."
+)
+
+// FstabDialect turns the rendered Linux fstab lines into the fstab flavour of another system.
+type FstabDialect interface {
+	Name() string
+	// ConvertLine maps types, options and fields to the dialect, or fails if the line cannot be expressed.
+	ConvertLine(line *FstabLine) (*FstabLine, error)
+	// FormatLine renders a converted line.
+	FormatLine(line *FstabLine) string
+}
+
+var FstabDialects = make(map[string]FstabDialect)
+
+func RegisterDialect(d FstabDialect) {
+	FstabDialects[d.Name()] = d
+}
+
+func LookupDialect(name string) (FstabDialect, bool) {
+	d, ok := FstabDialects[name]
+	return d, ok
+}
+
+func DialectNames() []string {
+	names := make([]string, 0, len(FstabDialects))
+	for name := range FstabDialects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ConvertFstabLines converts every line, stopping at the first one the dialect rejects with a *ConvertError.
+func ConvertFstabLines(d FstabDialect, entries []*FstabLine) ([]*FstabLine, error) {
+	converted := make([]*FstabLine, 0, len(entries))
+	for _, ent := range entries {
+		c, err := d.ConvertLine(ent)
+		if err != nil {
+			return nil, &ConvertError{Dialect: d.Name(), Entry: ent.Key(), Err: err}
+		}
+		converted = append(converted, c)
+	}
+	return converted, nil
+}
+
+// FormatDialectFstab renders lines already converted to the dialect, one per line.
+func FormatDialectFstab(d FstabDialect, entries []*FstabLine) string {
+	var b strings.Builder
+	for _, ent := range entries {
+		b.WriteString(d.FormatLine(ent) + "\n")
+	}
+	return b.String()
+}
+
+// SplitOptions splits a rendered option string, dropping "defaults".
+func SplitOptions(options string) []string {
+	opts := make([]string, 0)
+	for _, opt := range strings.Split(options, ",") {
+		if opt != "" && opt != "defaults" {
+			opts = append(opts, opt)
+		}
+	}
+	return opts
+}
+
+func OptionKey(opt string) string {
+	if i := strings.Index(opt, "="); i >= 0 {
+		return opt[:i]
+	}
+	return opt
+}
+
+// LinuxDialect writes the lines as they are rendered.
+type LinuxDialect struct{}
+
+func (LinuxDialect) Name() string {
+	return FormatFstab
+}
+
+func (LinuxDialect) ConvertLine(line *FstabLine) (*FstabLine, error) {
+	return line, nil
+}
+
+func (LinuxDialect) FormatLine(line *FstabLine) string {
+	return line.GenerateFstabEntryString()
+}
+
+func init() {
+	RegisterDialect(LinuxDialect{})
+}
@@ -0,0 +1,6 @@
+// Package fstab renders configs into fstab lines and writes, parses, merges and checks
+// fstab files in the Linux, BSD and vfstab dialects.
+//
+// Parse errors name the offending line, conversion errors name the mount point or
+// device of the entry that has no equivalent in the dialect.
+package fstab
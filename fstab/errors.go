@@ -0,0 +1,50 @@
+package fstab
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrWrite is matched by every error of writing an output file.
+	ErrWrite = errors.New("write error")
+	// ErrUnsupported is matched by the errors of a line the target dialect cannot express.
+	ErrUnsupported = errors.New("unsupported by the dialect")
+)
+
+// WriteError is a failure to write Path, Err is the cause.
+type WriteError struct {
+	Path string
+	Err  error
+}
+
+func (e *WriteError) Error() string {
+	return fmt.Sprintf("write %s: %s", e.Path, e.Err)
+}
+
+func (e *WriteError) Unwrap() error {
+	return e.Err
+}
+
+func (e *WriteError) Is(target error) bool {
+	return target == ErrWrite
+}
+
+// ConvertError is a line rejected by a dialect, Entry is the key of the line.
+type ConvertError struct {
+	Dialect string
+	Entry   string
+	Err     error
+}
+
+func (e *ConvertError) Error() string {
+	return fmt.Sprintf("%s: %s: %s", e.Dialect, e.Entry, e.Err)
+}
+
+func (e *ConvertError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ConvertError) Is(target error) bool {
+	return target == ErrUnsupported
+}
@@ -0,0 +1,45 @@
+package fstab
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"syscall"
+	"testing"
+
+	"tienbm90/yml2fstab/vfs"
+)
+
+func TestTypedErrors(t *testing.T) {
+	t.Run("write error", func(t *testing.T) {
+		fsys := vfs.NewMemoryFS()
+		dst := "/missing/fstab"
+		err := WriteFile(fsys, dst, []byte("\n"))
+		assert.True(t, errors.Is(err, ErrWrite))
+		var werr *WriteError
+		assert.True(t, errors.As(err, &werr))
+		assert.Equal(t, dst, werr.Path)
+
+		err = WriteFileAtomic(fsys, dst, []byte("\n"), ".bak")
+		assert.True(t, errors.Is(err, ErrWrite))
+
+		assert.NoError(t, fsys.MkdirAll("/etc", 0755))
+		fsys.Fail(vfs.OpWrite, "/etc/*", syscall.ENOSPC)
+		err = WriteFileAtomic(fsys, "/etc/fstab", []byte("\n"), ".bak")
+		assert.True(t, errors.Is(err, ErrWrite))
+		assert.True(t, errors.Is(err, syscall.ENOSPC))
+	})
+
+	t.Run("unsupported by the dialect", func(t *testing.T) {
+		d, _ := LookupDialect(FormatBSD)
+		_, err := ConvertFstabLines(d, []*FstabLine{NewFstabEntry("/dev/sda1", "/boot", "xfs", "defaults", 0, 0)})
+		assert.True(t, errors.Is(err, ErrUnsupported))
+		var cerr *ConvertError
+		assert.True(t, errors.As(err, &cerr))
+		assert.Equal(t, "/boot", cerr.Entry)
+		assert.Equal(t, "bsd: /boot: filesystem type xfs has no BSD equivalent", err.Error())
+
+		d, _ = LookupDialect(FormatVfstab)
+		_, err = ConvertFstabLines(d, []*FstabLine{NewFstabEntry("/dev/dsk/c0t0d0s1", "swap", "swap", "pri=1", 0, 0)})
+		assert.Equal(t, "vfstab: /dev/dsk/c0t0d0s1: swap option pri=1 is not supported", err.Error())
+	})
+}
@@ -0,0 +1,154 @@
+package fstab
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"tienbm90/yml2fstab/vfs"
+)
+
+func WriteFstabFileContentToTempFile(fsys vfs.FS, entries []*FstabLine, dst string) error {
+	return WriteDialectFileContentToTempFile(fsys, LinuxDialect{}, entries, dst)
+}
+
+// WriteDialectFileContentToTempFile writes entries already converted to the dialect to dst
+// with WriteFile, dst is left as it was when the write fails.
+func WriteDialectFileContentToTempFile(fsys vfs.FS, dialect FstabDialect, entries []*FstabLine, dst string) error {
+	var b bytes.Buffer
+	if err := WriteDialectFstab(&b, dialect, entries); err != nil {
+		return &WriteError{Path: dst, Err: err}
+	}
+	return WriteFile(fsys, dst, b.Bytes())
+}
+
+// WriteDialectFstab writes entries already converted to the dialect to w, one line each.
+func WriteDialectFstab(w io.Writer, dialect FstabDialect, entries []*FstabLine) error {
+	writer := bufio.NewWriter(w)
+	for _, ent := range entries {
+		if _, err := writer.WriteString(dialect.FormatLine(ent) + "\n"); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
+
+// ReadFstabFile parses the entries of an existing fstab of fsys.
+func ReadFstabFile(fsys vfs.FS, path string) ([]*FstabLine, error) {
+	file, err := fsys.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadFstab(file)
+}
+
+// ReadFstab parses the entries of a fstab read from r until EOF.
+func ReadFstab(r io.Reader) ([]*FstabLine, error) {
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return ParseFstab(string(content))
+}
+
+// CopyFile copies the regular file src to dst with WriteFileAtomic, a dst created keeps the
+// mode of src. dst is left as it was when the copy fails.
+func CopyFile(fsys vfs.FS, src, dst string) error {
+
+	// check if file exists
+	sourceFileStat, err := fsys.Stat(src)
+	if err != nil {
+		return err
+	}
+
+	// check if file is regular file
+	if !sourceFileStat.Mode().IsRegular() {
+		return fmt.Errorf("%s is not a regular file", src)
+	}
+
+	//read source file
+	content, err := vfs.ReadFile(fsys, src)
+	if err != nil {
+		return err
+	}
+
+	//replace dst file
+	return writeFileAtomic(fsys, dst, content, "", sourceFileStat.Mode().Perm())
+}
+
+// WriteFile replaces dst of fsys with content as WriteFileAtomic does, without a backup.
+// Errors are a *WriteError.
+func WriteFile(fsys vfs.FS, dst string, content []byte) error {
+	return WriteFileAtomic(fsys, dst, content, "")
+}
+
+// WriteFileAtomic replaces dst of fsys with content through a temp file in the same directory,
+// readers see the old or the new file but never a partial one and the temp file is removed
+// whatever fails. The mode of an existing dst is kept, a new one is created 0644.
+// When backup is not empty the previous dst is copied there before it is replaced. A dst
+// that is a symlink is kept, the file it points to is replaced. Errors are a *WriteError.
+func WriteFileAtomic(fsys vfs.FS, dst string, content []byte, backup string) error {
+	if err := writeFileAtomic(fsys, dst, content, backup, 0644); err != nil {
+		return &WriteError{Path: dst, Err: err}
+	}
+	return nil
+}
+
+// writeFileAtomic is WriteFileAtomic creating a new dst with mode.
+func writeFileAtomic(fsys vfs.FS, dst string, content []byte, backup string, mode os.FileMode) error {
+	dst, err := resolveLink(fsys, dst)
+	if err != nil {
+		return err
+	}
+	info, err := fsys.Stat(dst)
+	switch {
+	case err == nil:
+		mode = info.Mode().Perm()
+	case !os.IsNotExist(err):
+		return err
+	}
+
+	tmp, err := fsys.TempFile(filepath.Dir(dst), "."+filepath.Base(dst)+".tmp-")
+	if err != nil {
+		return err
+	}
+	defer fsys.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := fsys.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+
+	if backup != "" && info != nil {
+		if err := CopyFile(fsys, dst, backup); err != nil {
+			return fmt.Errorf("backup to %s: %w", backup, err)
+		}
+	}
+	return fsys.Rename(tmp.Name(), dst)
+}
+
+// resolveLink follows dst as long as it is a symlink and returns the file it points to,
+// which may not exist yet.
+func resolveLink(fsys vfs.FS, dst string) (string, error) {
+	resolved, err := vfs.Resolve(dst, true, vfs.LinkReader(fsys, ""))
+	if err == syscall.ELOOP {
+		return "", &os.PathError{Op: "resolve", Path: dst, Err: err}
+	}
+	return resolved, err
+}
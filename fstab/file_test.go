@@ -0,0 +1,183 @@
+package fstab
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"testing/iotest"
+
+	"tienbm90/yml2fstab/vfs"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	t.Run("new file", func(t *testing.T) {
+		dir := t.TempDir()
+		dst := filepath.Join(dir, "fstab")
+		assert.NoError(t, WriteFileAtomic(vfs.NewOSFS(), dst, []byte("/dev/sda1 /boot xfs defaults 0 0\n"), dst+".bak"))
+
+		content, err := ioutil.ReadFile(dst)
+		assert.NoError(t, err)
+		assert.Equal(t, "/dev/sda1 /boot xfs defaults 0 0\n", string(content))
+		_, err = os.Stat(dst + ".bak")
+		assert.True(t, os.IsNotExist(err))
+
+		files, _ := ioutil.ReadDir(dir)
+		assert.Equal(t, 1, len(files))
+	})
+
+	t.Run("replace with backup", func(t *testing.T) {
+		dir := t.TempDir()
+		dst := filepath.Join(dir, "fstab")
+		assert.NoError(t, ioutil.WriteFile(dst, []byte("old\n"), 0600))
+		assert.NoError(t, WriteFileAtomic(vfs.NewOSFS(), dst, []byte("new\n"), dst+".bak"))
+
+		content, _ := ioutil.ReadFile(dst)
+		assert.Equal(t, "new\n", string(content))
+		backup, _ := ioutil.ReadFile(dst + ".bak")
+		assert.Equal(t, "old\n", string(backup))
+
+		info, err := os.Stat(dst)
+		assert.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	})
+
+	t.Run("missing directory", func(t *testing.T) {
+		assert.Error(t, WriteFileAtomic(vfs.NewOSFS(), filepath.Join(t.TempDir(), "missing", "fstab"), []byte("new\n"), ""))
+	})
+
+	t.Run("symlink is kept", func(t *testing.T) {
+		fsys := newTestFS(t)
+		assert.NoError(t, fsys.MkdirAll("/usr/etc", 0755))
+		assert.NoError(t, fsys.Rename("/etc/fstab", "/usr/etc/fstab"))
+		assert.NoError(t, fsys.Symlink("../usr/etc/fstab", "/etc/fstab"))
+		assert.NoError(t, WriteFileAtomic(fsys, "/etc/fstab", []byte("new\n"), "/etc/fstab.bak"))
+
+		target, err := fsys.Readlink("/etc/fstab")
+		assert.NoError(t, err)
+		assert.Equal(t, "../usr/etc/fstab", target)
+		content, _ := vfs.ReadFile(fsys, "/usr/etc/fstab")
+		assert.Equal(t, "new\n", string(content))
+		backup, _ := vfs.ReadFile(fsys, "/etc/fstab.bak")
+		assert.Equal(t, "old\n", string(backup))
+	})
+}
+
+// newTestFS returns a file system holding /etc/fstab.
+func newTestFS(t *testing.T) *vfs.MemoryFS {
+	fsys := vfs.NewMemoryFS()
+	assert.NoError(t, fsys.MkdirAll("/etc", 0755))
+	assert.NoError(t, vfs.WriteFile(fsys, "/etc/fstab", []byte("old\n"), 0644))
+	return fsys
+}
+
+func TestWriteFileAtomicFaults(t *testing.T) {
+	// backup is the backup left, the previous one unless only the final rename failed
+	faults := map[string]struct {
+		op      vfs.Op
+		pattern string
+		backup  string
+	}{
+		"temp file not created": {vfs.OpOpen, "/etc/.fstab.tmp-*", "older\n"},
+		"disk full":             {vfs.OpWrite, "/etc/.fstab.tmp-*", "older\n"},
+		"sync":                  {vfs.OpSync, "/etc/.fstab.tmp-*", "older\n"},
+		"close":                 {vfs.OpClose, "/etc/.fstab.tmp-*", "older\n"},
+		"chmod":                 {vfs.OpChmod, "/etc/.fstab.tmp-*", "older\n"},
+		"backup not created":    {vfs.OpOpen, "/etc/.fstab.bak.tmp-*", "older\n"},
+		"backup not written":    {vfs.OpWrite, "/etc/.fstab.bak.tmp-*", "older\n"},
+		"backup not closed":     {vfs.OpClose, "/etc/.fstab.bak.tmp-*", "older\n"},
+		"backup not renamed":    {vfs.OpRename, "/etc/fstab.bak", "older\n"},
+		"rename":                {vfs.OpRename, "/etc/fstab", "old\n"},
+	}
+	for name, f := range faults {
+		t.Run(name, func(t *testing.T) {
+			fsys := newTestFS(t)
+			assert.NoError(t, vfs.WriteFile(fsys, "/etc/fstab.bak", []byte("older\n"), 0644))
+			fsys.Fail(f.op, f.pattern, syscall.ENOSPC)
+
+			err := WriteFileAtomic(fsys, "/etc/fstab", []byte("new\n"), "/etc/fstab.bak")
+			assert.True(t, errors.Is(err, ErrWrite))
+			assert.True(t, errors.Is(err, syscall.ENOSPC))
+
+			// the fstab is untouched and the temp files removed
+			fsys.ClearFaults()
+			content, _ := vfs.ReadFile(fsys, "/etc/fstab")
+			assert.Equal(t, "old\n", string(content))
+			backup, _ := vfs.ReadFile(fsys, "/etc/fstab.bak")
+			assert.Equal(t, f.backup, string(backup))
+			for _, p := range fsys.Paths() {
+				assert.False(t, strings.Contains(p, ".tmp-"), p)
+			}
+		})
+	}
+
+	t.Run("permission denied", func(t *testing.T) {
+		fsys := newTestFS(t)
+		fsys.Fail(vfs.OpStat, "/etc/fstab", syscall.EACCES)
+		err := WriteFileAtomic(fsys, "/etc/fstab", []byte("new\n"), "")
+		assert.True(t, os.IsPermission(errors.Unwrap(err)))
+	})
+}
+
+func TestWriteDialectFileContentToTempFile(t *testing.T) {
+	entries := []*FstabLine{NewFstabEntry("/dev/sda1", "/boot", "xfs", "defaults", 0, 0)}
+
+	t.Run("success", func(t *testing.T) {
+		fsys := newTestFS(t)
+		assert.NoError(t, WriteFstabFileContentToTempFile(fsys, entries, "/etc/fstab"))
+		content, _ := vfs.ReadFile(fsys, "/etc/fstab")
+		assert.Equal(t, "/dev/sda1 /boot xfs defaults 0 0\n", string(content))
+		assert.Equal(t, []string{"/etc", "/etc/fstab"}, fsys.Paths())
+	})
+
+	// a full disk is reported by the write, the sync or the close depending on the filesystem
+	for _, op := range []vfs.Op{vfs.OpOpen, vfs.OpWrite, vfs.OpSync, vfs.OpClose} {
+		t.Run(string(op), func(t *testing.T) {
+			fsys := newTestFS(t)
+			fsys.Fail(op, "/etc/.fstab.tmp-*", syscall.ENOSPC)
+			err := WriteFstabFileContentToTempFile(fsys, entries, "/etc/fstab")
+			assert.True(t, errors.Is(err, ErrWrite))
+			assert.True(t, errors.Is(err, syscall.ENOSPC))
+
+			content, _ := vfs.ReadFile(fsys, "/etc/fstab")
+			assert.Equal(t, "old\n", string(content))
+			assert.Equal(t, []string{"/etc", "/etc/fstab"}, fsys.Paths())
+		})
+	}
+}
+
+// failingWriter fails every write, so a buffered writer only fails when it is flushed.
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, syscall.ENOSPC
+}
+
+func TestStreams(t *testing.T) {
+	t.Run("write", func(t *testing.T) {
+		var b strings.Builder
+		entries := []*FstabLine{NewFstabEntry("/dev/sda1", "/boot", "xfs", "defaults", 0, 0)}
+		assert.NoError(t, WriteDialectFstab(&b, LinuxDialect{}, entries))
+		assert.Equal(t, "/dev/sda1 /boot xfs defaults 0 0\n", b.String())
+	})
+
+	t.Run("flush", func(t *testing.T) {
+		entries := []*FstabLine{NewFstabEntry("/dev/sda1", "/boot", "xfs", "defaults", 0, 0)}
+		err := WriteDialectFstab(failingWriter{}, LinuxDialect{}, entries)
+		assert.True(t, errors.Is(err, syscall.ENOSPC))
+	})
+
+	t.Run("read", func(t *testing.T) {
+		entries, err := ReadFstab(strings.NewReader("# boot\n/dev/sda1 /boot xfs defaults 0 0\n"))
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(entries))
+		assert.Equal(t, "/boot", entries[0].MountPoint)
+
+		_, err = ReadFstab(iotest.ErrReader(errors.New("broken pipe")))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,312 @@
+package fstab
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"tienbm90/yml2fstab/config"
+)
+
+type FstabLine struct {
+	//usually the given name or UUID of the mounted device
+	Device string `json:"device"`
+	//designates the directory where the device is/will be mounted.
+	MountPoint string `json:"mount_point"`
+
+	// shows the type of filesystem in use.
+	FileSystemType string `json:"type"`
+	// lists any active mount options. If using multiple options they must be separated by commas.
+	Options string `json:"options"`
+
+	//1 = dump utility backup of a partition. 0 = no backup. This is an outdated backup method and should NOT be used.
+	BackupOperation int `json:"dump"`
+
+	//0 means that fsck will not check the filesystem. Numbers higher than this represent the check order. The root filesystem should be set to 1 and other partitions set to 2
+	FileSystemCheckOrder int `json:"pass"`
+
+	//free text written above the line by the pretty writer
+	Comment string `json:"comment,omitempty"`
+}
+
+func (ent *FstabLine) IsFileSystemTypeValid() bool {
+	return config.CheckFileSystemTypeValid(ent.FileSystemType)
+}
+
+func (ent *FstabLine) IsBackupOperationValid() bool {
+	if ent.BackupOperation < 0 || ent.BackupOperation > 1 {
+		return false
+	}
+	return true
+}
+
+func (ent *FstabLine) IsFileSystemCheckOrderValid() bool {
+	if ent.FileSystemCheckOrder < 0 || ent.FileSystemCheckOrder > 2 {
+		return false
+	}
+	// swap is never checked by fsck
+	if ent.FileSystemType == "swap" && ent.FileSystemCheckOrder != 0 {
+		return false
+	}
+
+	return true
+}
+
+func (ent *FstabLine) IsValid() bool {
+	return ent.IsBackupOperationValid() &&
+		ent.IsFileSystemCheckOrderValid() &&
+		ent.IsFileSystemTypeValid() &&
+		ent.IsMountPointValid()
+}
+
+func (ent *FstabLine) IsMountPointValid() bool {
+	return config.CheckMountPointValid(ent.MountPoint)
+}
+
+func (ent *FstabLine) SetDevice(dvc string) {
+	ent.Device = dvc
+}
+
+func (ent *FstabLine) SetMountPoint(mp string) {
+	ent.MountPoint = mp
+}
+
+func (ent *FstabLine) SetFileSystemType(fst string) {
+	ent.FileSystemType = fst
+}
+
+func (ent *FstabLine) SetOptions(opts string) {
+	ent.Options = opts
+}
+
+func (ent *FstabLine) SetBackupOperation(bo int) {
+	ent.BackupOperation = bo
+}
+
+func (ent *FstabLine) SetFileSystemCheckOrder(fsco int) {
+	ent.FileSystemCheckOrder = fsco
+
+}
+
+// Key identifies an entry, swap lines share their mount point and are keyed by device.
+func (ent *FstabLine) Key() string {
+	if ent.FileSystemType == "swap" {
+		return ent.Device
+	}
+	return ent.MountPoint
+}
+
+// FstabLineFields returns the six columns of the line as they are written. Every writer goes
+// through it, so whitespace and backslashes are escaped the same way everywhere and a mount
+// point such as "/srv/my data" stays one field.
+func FstabLineFields(ent *FstabLine) []string {
+	return []string{
+		EscapeFstabField(ent.Device),
+		EscapeFstabField(ent.MountPoint),
+		EscapeFstabField(ent.FileSystemType),
+		EscapeFstabField(ent.Options),
+		strconv.Itoa(ent.BackupOperation),
+		strconv.Itoa(ent.FileSystemCheckOrder),
+	}
+}
+
+// GenerateFstabEntryString writes the line in the fstab(5) format.
+func (ent *FstabLine) GenerateFstabEntryString() string {
+	return strings.Join(FstabLineFields(ent), " ")
+}
+
+func NewFstabEntry(device string,
+	mountPoint string,
+	fileSystemType string,
+	options string,
+	backupOperation int,
+	fileSystemCheckOrder int) *FstabLine {
+	return &FstabLine{
+		Device:               device,
+		MountPoint:           mountPoint,
+		FileSystemType:       fileSystemType,
+		Options:              options,
+		BackupOperation:      backupOperation,
+		FileSystemCheckOrder: fileSystemCheckOrder,
+	}
+}
+
+func NewFstabLineFromConfig(c config.Config) (*FstabLine, error) {
+	device, err := config.RenderMountDevice(&c)
+	if err != nil {
+		return nil, err
+	}
+	ent := NewFstabLineWithOptions(
+		WithDevice(device),
+		WithMountPoint(c.GetMountPoint()),
+		WithOptions(c.GenerateOptionString()),
+		WithFileSystemType(c.GetFileSystemType()),
+		WithBackupOperation(c.GetBackupOperation()),
+		WithFileSystemCheckOrder(c.GetFileSystemCheckOrder()),
+		WithComment(c.Comment),
+	)
+	return ent, nil
+}
+
+// FstabLineRenderer is implemented by filesystem types that render the whole line themselves.
+type FstabLineRenderer interface {
+	RenderFstabLine(c *config.Config) (*FstabLine, error)
+}
+
+// RenderFstabLine renders c with its registered type, falling back to NewFstabLineFromConfig.
+func RenderFstabLine(c *config.Config) (*FstabLine, error) {
+	if t, ok := config.LookupFileSystemType(c.GetFileSystemType()); ok {
+		if renderer, ok := t.(FstabLineRenderer); ok {
+			ent, err := renderer.RenderFstabLine(c)
+			if err == nil && ent.Comment == "" {
+				ent.Comment = c.Comment
+			}
+			return ent, err
+		}
+	}
+	return NewFstabLineFromConfig(*c)
+}
+
+// RenderFstabLines renders every config with its registered type.
+func RenderFstabLines(configs []*config.Config) ([]*FstabLine, error) {
+	entries := make([]*FstabLine, 0, len(configs))
+	for _, cnf := range configs {
+		ent, err := RenderFstabLine(cnf)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, ent)
+	}
+	return entries, nil
+}
+
+type FstabLineOption func(entry *FstabLine)
+
+func NewFstabLineWithOptions(options ...FstabLineOption) *FstabLine {
+	line := &FstabLine{}
+	for _, opt := range options {
+		opt(line)
+	}
+	return line
+}
+
+func WithDevice(device string) FstabLineOption {
+	return func(line *FstabLine) {
+		line.Device = device
+	}
+}
+func WithMountPoint(mountpoint string) FstabLineOption {
+	return func(line *FstabLine) {
+		line.MountPoint = mountpoint
+	}
+}
+
+func WithOptions(options string) FstabLineOption {
+	return func(line *FstabLine) {
+		line.Options = options
+	}
+}
+
+func WithFileSystemType(fsType string) FstabLineOption {
+	return func(line *FstabLine) {
+		line.FileSystemType = fsType
+	}
+}
+
+func WithFileSystemCheckOrder(fsco int) FstabLineOption {
+	return func(line *FstabLine) {
+		line.FileSystemCheckOrder = fsco
+	}
+}
+
+func WithBackupOperation(bo int) FstabLineOption {
+	return func(line *FstabLine) {
+		line.BackupOperation = bo
+	}
+}
+
+func WithComment(comment string) FstabLineOption {
+	return func(line *FstabLine) {
+		line.Comment = comment
+	}
+}
+
+// UnescapeFstabField decodes the octal escapes of fstab(5) and mountinfo, \040 for a space.
+func UnescapeFstabField(field string) string {
+	if !strings.Contains(field, "\\") {
+		return field
+	}
+	var b strings.Builder
+	for i := 0; i < len(field); i++ {
+		if field[i] == '\\' && i+4 <= len(field) {
+			if v, err := strconv.ParseUint(field[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(v))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(field[i])
+	}
+	return b.String()
+}
+
+// EscapeFstabField encodes the characters fstab(5) fields cannot hold as octal escapes.
+func EscapeFstabField(field string) string {
+	if !strings.ContainsAny(field, " \t\n\\") {
+		return field
+	}
+	var b strings.Builder
+	for i := 0; i < len(field); i++ {
+		switch c := field[i]; c {
+		case ' ', '\t', '\n', '\\':
+			fmt.Fprintf(&b, "\\%03o", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// ParseFstabLine parses a line of the fstab(5) format, dump and pass default to 0.
+// It returns nil for blank and comment lines.
+func ParseFstabLine(line string) (*FstabLine, error) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil, nil
+	}
+	fields := strings.Fields(line)
+	if len(fields) < 4 || len(fields) > 6 {
+		return nil, fmt.Errorf("invalid fstab line %q: expected 4 to 6 fields, got %d", line, len(fields))
+	}
+	numbers := []int{0, 0}
+	for i, f := range fields[4:] {
+		n, err := strconv.Atoi(f)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fstab line %q: %s is not a number", line, f)
+		}
+		numbers[i] = n
+	}
+	return NewFstabLineWithOptions(
+		WithDevice(UnescapeFstabField(fields[0])),
+		WithMountPoint(UnescapeFstabField(fields[1])),
+		WithFileSystemType(UnescapeFstabField(fields[2])),
+		WithOptions(UnescapeFstabField(fields[3])),
+		WithBackupOperation(numbers[0]),
+		WithFileSystemCheckOrder(numbers[1]),
+	), nil
+}
+
+// ParseFstab parses every entry of a fstab, skipping blank and comment lines.
+func ParseFstab(content string) ([]*FstabLine, error) {
+	entries := make([]*FstabLine, 0)
+	for i, line := range strings.Split(content, "\n") {
+		ent, err := ParseFstabLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", i+1, err)
+		}
+		if ent != nil {
+			entries = append(entries, ent)
+		}
+	}
+	return entries, nil
+}
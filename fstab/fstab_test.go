@@ -1,8 +1,11 @@
-package main
+package fstab
 
 import (
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
+
+	"tienbm90/yml2fstab/config"
 )
 
 func TestNewFstabEntry(t *testing.T) {
@@ -107,10 +110,11 @@ func TestNewFstabEntryFromConfig(t *testing.T) {
 			opti[i] = opts[i]
 		}
 		data["options"] = opti
-		cnf, err := NewConfigFromMapData("192.168.4.6", data)
+		cnf, err := config.NewConfigFromMapData("192.168.4.6", data)
 		assert.NoError(t, err, nil)
 
-		ent := NewFstabLineFromConfig(*cnf)
+		ent, err := NewFstabLineFromConfig(*cnf)
+		assert.NoError(t, err)
 		assert.Equal(t, ent.FileSystemCheckOrder, 0)
 		assert.Equal(t, ent.FileSystemType, cnf.GetFileSystemType())
 		assert.Equal(t, ent.MountPoint, cnf.GetMountPoint())
@@ -119,13 +123,102 @@ func TestNewFstabEntryFromConfig(t *testing.T) {
 		assert.Equal(t, ent.GenerateFstabEntryString(), "192.168.4.6:/var/nfs/home /home nfs noexec,nosuid 0 0")
 	})
 
+	t.Run("swap", func(t *testing.T) {
+		data := map[string]interface{}{"priority": 10, "discard": "once", "nofail": true}
+		cnf, err := config.NewSwapConfigFromMapData("/dev/sda3", data)
+		assert.NoError(t, err)
+
+		ent, err := NewFstabLineFromConfig(*cnf)
+		assert.NoError(t, err)
+		assert.Equal(t, ent.GenerateFstabEntryString(), "/dev/sda3 swap swap pri=10,discard=once,nofail 0 0")
+	})
+
 	t.Run("error. Require string for options field", func(t *testing.T) {
 		data := make(map[string]interface{})
 		data["mount"] = "/home"
 		data["export"] = "/var/nfs/home"
 		data["type"] = "nfs"
 		data["options"] = "noexec"
-		_, err := NewConfigFromMapData("192.168.4.6", data)
+		_, err := config.NewConfigFromMapData("192.168.4.6", data)
 		assert.Error(t, err, nil)
 	})
 }
+
+func TestParseFstab(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		entries, err := ParseFstab(`# /etc/fstab
+UUID=5e1a        /          ext4 defaults 0 1
+
+/dev/sdb1	/srv/my\040data	xfs	noatime
+192.168.4.5:/var/nfs/home /home nfs noexec,nosuid 0
+`)
+		assert.NoError(t, err)
+		assert.Equal(t, []*FstabLine{
+			NewFstabEntry("UUID=5e1a", "/", "ext4", "defaults", 0, 1),
+			NewFstabEntry("/dev/sdb1", "/srv/my data", "xfs", "noatime", 0, 0),
+			NewFstabEntry("192.168.4.5:/var/nfs/home", "/home", "nfs", "noexec,nosuid", 0, 0),
+		}, entries)
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		ent := NewFstabEntry("/dev/sda3", "swap", "swap", "pri=10", 0, 0)
+		parsed, err := ParseFstabLine(ent.GenerateFstabEntryString())
+		assert.NoError(t, err)
+		assert.Equal(t, ent, parsed)
+
+		ent = NewFstabEntry("LABEL=my\\disk", "/srv/my data", "ext4", "defaults", 0, 2)
+		assert.Equal(t, `LABEL=my\134disk /srv/my\040data ext4 defaults 0 2`, ent.GenerateFstabEntryString())
+		parsed, err = ParseFstabLine(ent.GenerateFstabEntryString())
+		assert.NoError(t, err)
+		assert.Equal(t, ent, parsed)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := ParseFstab("/dev/sdb1 /data ext4\n")
+		assert.Error(t, err)
+		_, err = ParseFstab("/dev/sdb1 /data ext4 defaults 0 2 extra\n")
+		assert.Error(t, err)
+		_, err = ParseFstab("/dev/sdb1 /data ext4 defaults zero 2\n")
+		assert.Error(t, err)
+	})
+
+	t.Run("unescape", func(t *testing.T) {
+		assert.Equal(t, "/srv/a b\tc", UnescapeFstabField(`/srv/a\040b\011c`))
+		assert.Equal(t, `/srv/a\b`, UnescapeFstabField(`/srv/a\b`))
+		assert.Equal(t, `/srv/\999`, UnescapeFstabField(`/srv/\999`))
+		assert.Equal(t, `/srv/\04`, UnescapeFstabField(`/srv/\04`))
+	})
+}
+
+func TestFstabLineFieldsRoundTrip(t *testing.T) {
+	t.Run("mount point with a space", func(t *testing.T) {
+		cnf, err := config.NewConfigFromMapData("/dev/sdc1", map[string]interface{}{
+			"mount": "/srv/my data",
+			"type":  "xfs",
+		})
+		assert.NoError(t, err)
+		entries, err := RenderFstabLines([]*config.Config{cnf})
+		assert.NoError(t, err)
+
+		merged, err := MergeFstab("/dev/sda2 / ext4 defaults 0 1\n", entries, nil)
+		assert.NoError(t, err)
+		marshaled, err := (&Fstab{Lines: entries}).Marshal()
+		assert.NoError(t, err)
+		written := map[string]string{
+			"plain":   FormatDialectFstab(LinuxDialect{}, entries),
+			"bsd":     FormatDialectFstab(BSDDialect{}, entries),
+			"pretty":  FormatPrettyFstab(NewFstabHeader("input.yml", nil, time.Now()), entries),
+			"merge":   merged.Content(),
+			"marshal": string(marshaled),
+		}
+		for name, content := range written {
+			assert.Contains(t, content, `/srv/my\040data`, name)
+			parsed, err := ParseFstab(content)
+			assert.NoError(t, err, name)
+			last := parsed[len(parsed)-1]
+			assert.Equal(t, "/dev/sdc1", last.Device, name)
+			assert.Equal(t, "/srv/my data", last.MountPoint, name)
+			assert.Equal(t, "xfs", last.FileSystemType, name)
+		}
+	})
+}
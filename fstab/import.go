@@ -0,0 +1,140 @@
+package fstab
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"tienbm90/yml2fstab/config"
+)
+
+// ImportedConfig is a config read back from a fstab line.
+type ImportedConfig struct {
+	Config *config.Config
+	Line   *FstabLine
+	// Dropped lists what the YAML cannot express, the line renders differently without it.
+	Dropped []string
+}
+
+// splitMountDevice finds the source and export that render back to the device of ent,
+// host:/path for nfs and //host/share for cifs, the device itself for the other types.
+// It returns false when no source renders back, the device is then kept as the source.
+func splitMountDevice(ent *FstabLine) (string, string, bool) {
+	candidates := make([][2]string, 0)
+	if strings.HasPrefix(ent.Device, "//") {
+		if i := strings.Index(ent.Device[2:], "/"); i > 0 {
+			candidates = append(candidates, [2]string{ent.Device[2 : i+2], ent.Device[i+2:]})
+		}
+	}
+	if i := strings.Index(ent.Device, ":/"); i > 0 {
+		candidates = append(candidates, [2]string{ent.Device[:i], ent.Device[i+1:]})
+	}
+	candidates = append(candidates, [2]string{ent.Device, ""})
+	for _, c := range candidates {
+		cnf := config.NewConfigWithOptions(
+			config.WithConfigSource(c[0]),
+			config.WithConfigExport(c[1]),
+			config.WithConfigFSType(ent.FileSystemType))
+		if device, err := config.RenderMountDevice(cnf); err == nil && device == ent.Device {
+			return c[0], c[1], true
+		}
+	}
+	return ent.Device, "", false
+}
+
+func importSwapLine(ent *FstabLine) (*ImportedConfig, error) {
+	data := make(map[string]interface{})
+	dropped := make([]string, 0)
+	for _, opt := range SplitOptions(ent.Options) {
+		key, value := OptionKey(opt), strings.TrimPrefix(opt, OptionKey(opt)+"=")
+		switch {
+		case key == "pri" && opt != key:
+			priority, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("swap %s: invalid priority %s", ent.Device, value)
+			}
+			data["priority"] = priority
+		case opt == "discard":
+			data["discard"] = true
+		case key == "discard":
+			data["discard"] = value
+		case opt == "nofail":
+			data["nofail"] = true
+		case opt == "sw":
+			// swapon ignores it, it only fills the options column
+		default:
+			dropped = append(dropped, "option "+opt)
+		}
+	}
+	if ent.Comment != "" {
+		data["comment"] = ent.Comment
+	}
+	if ent.MountPoint != config.SwapMountPoint {
+		dropped = append(dropped, "mount point "+ent.MountPoint)
+	}
+	cnf, err := config.NewSwapConfigFromMapData(ent.Device, data)
+	if err != nil {
+		return nil, err
+	}
+	return &ImportedConfig{Config: cnf, Line: ent, Dropped: dropped}, nil
+}
+
+// ImportFstabLine converts a fstab line to a config. The pass comes from the type of the
+// entry and dump is always 0, other values are listed as dropped.
+func ImportFstabLine(ent *FstabLine) (*ImportedConfig, error) {
+	if ent.Device == "" || ent.MountPoint == "" || ent.FileSystemType == "" {
+		return nil, errors.New("device, mount point and type are required to import a fstab line")
+	}
+
+	var imported *ImportedConfig
+	if ent.FileSystemType == "swap" {
+		var err error
+		imported, err = importSwapLine(ent)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		source, export, ok := splitMountDevice(ent)
+		cnf := config.NewConfigWithOptions(
+			config.WithConfigSource(source),
+			config.WithConfigExport(export),
+			config.WithConfigMount(ent.MountPoint),
+			config.WithConfigFSType(ent.FileSystemType),
+			config.WithConfigOptions(SplitOptions(ent.Options)),
+			config.WithConfigComment(ent.Comment),
+		)
+		cnf.FileSystemCheckOrder = config.DefaultFileSystemPass(cnf)
+		imported = &ImportedConfig{Config: cnf, Line: ent, Dropped: make([]string, 0)}
+		if !ok {
+			rendered, err := config.RenderMountDevice(cnf)
+			if err != nil {
+				return nil, err
+			}
+			imported.Dropped = append(imported.Dropped, fmt.Sprintf("device %s, the %s source renders as %s",
+				ent.Device, ent.FileSystemType, rendered))
+		}
+	}
+
+	if ent.BackupOperation != 0 {
+		imported.Dropped = append(imported.Dropped, fmt.Sprintf("dump %d", ent.BackupOperation))
+	}
+	if ent.FileSystemCheckOrder != imported.Config.FileSystemCheckOrder {
+		imported.Dropped = append(imported.Dropped, fmt.Sprintf("pass %d, the %s default is %d",
+			ent.FileSystemCheckOrder, ent.FileSystemType, imported.Config.FileSystemCheckOrder))
+	}
+	return imported, nil
+}
+
+// ImportFstab converts every line of f, stopping at the first one that cannot be converted.
+func ImportFstab(f *Fstab) ([]*ImportedConfig, error) {
+	imported := make([]*ImportedConfig, 0, f.Len())
+	for _, ent := range f.Lines {
+		i, err := ImportFstabLine(ent)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", ent.Key(), err)
+		}
+		imported = append(imported, i)
+	}
+	return imported, nil
+}
@@ -0,0 +1,100 @@
+package fstab
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+
+	"tienbm90/yml2fstab/config"
+)
+
+func TestImportFstabLine(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		lines := []string{
+			"/dev/sda2 / ext4 defaults 0 1",
+			"/dev/sdb1 /data ext4 noatime,nodev 0 2",
+			"192.168.4.5:/var/nfs/home /home nfs noexec,nosuid 0 0",
+			"//fileserver/share /mnt/share cifs credentials=/etc/smb.cred 0 0",
+			"/dev/sda3 swap swap pri=10,discard=once,nofail 0 0",
+			"/swapfile swap swap discard 0 0",
+		}
+		for _, line := range lines {
+			ent, err := ParseFstabLine(line)
+			assert.NoError(t, err)
+			imported, err := ImportFstabLine(ent)
+			assert.NoError(t, err, line)
+			assert.Empty(t, imported.Dropped, line)
+			generated, err := NewFstabLineFromConfig(*imported.Config)
+			assert.NoError(t, err)
+			assert.Equal(t, line, generated.GenerateFstabEntryString())
+		}
+
+		ent, _ := ParseFstabLine("192.168.4.5:/var/nfs/home /home nfs defaults 0 0")
+		imported, _ := ImportFstabLine(ent)
+		assert.Equal(t, "192.168.4.5", imported.Config.Source)
+		assert.Equal(t, "/var/nfs/home", imported.Config.Export)
+	})
+
+	t.Run("dropped", func(t *testing.T) {
+		ent, _ := ParseFstabLine("/dev/sda1 /boot xfs defaults 1 2")
+		imported, err := ImportFstabLine(ent)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"dump 1", "pass 2, the xfs default is 0"}, imported.Dropped)
+
+		ent, _ = ParseFstabLine("/dev/sda3 none swap sw,x-systemd.makefs 0 0")
+		imported, err = ImportFstabLine(ent)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"option x-systemd.makefs", "mount point none"}, imported.Dropped)
+	})
+
+	t.Run("unknown type with a host like device", func(t *testing.T) {
+		ent, _ := ParseFstabLine("myhost /mnt/legacy legacyfs defaults 0 0")
+		imported, err := ImportFstabLine(ent)
+		assert.NoError(t, err)
+		assert.Equal(t, "myhost", imported.Config.Source)
+		assert.Equal(t, []string{"device myhost, the legacyfs source renders as myhost:"}, imported.Dropped)
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		_, err := ImportFstabLine(NewFstabEntry("/dev/sda3", "swap", "swap", "pri=high", 0, 0))
+		assert.Error(t, err)
+		_, err = ImportFstabLine(NewFstabEntry("swapfile", "swap", "swap", "defaults", 0, 0))
+		assert.Error(t, err)
+		_, err = ImportFstab(NewFstab(NewFstabEntry("/dev/sda1", "/boot", "", "defaults", 0, 0)))
+		assert.Error(t, err)
+	})
+}
+
+func TestImportFstabRoundTrip(t *testing.T) {
+	// the kernel pseudo filesystems are registered from /proc/filesystems by the command
+	for _, name := range []string{"proc", "sysfs"} {
+		config.RegisterFileSystemType(config.NewFileSystemType(name))
+		defer config.DefaultFileSystemRegistry.Unregister(name)
+	}
+
+	t.Run("stock fstab", func(t *testing.T) {
+		stock := `UUID=5e1a / ext4 errors=remount-ro 0 1
+UUID=0a34 /boot/efi vfat umask=0077 0 2
+proc /proc proc defaults 0 0
+sysfs /sys sysfs defaults 0 0
+devpts /dev/pts devpts gid=5,mode=620 0 0
+tmpfs /tmp tmpfs mode=1777,size=2g 0 0
+tmpfs /run/user tmpfs size=10% 0 0
+192.168.4.5:/var/nfs/home /home nfs noexec,nosuid 0 0
+//fileserver/share /mnt/share cifs credentials=/etc/smb.cred 0 0
+/swapfile swap swap defaults 0 0
+`
+		f := NewFstab()
+		assert.NoError(t, f.Unmarshal([]byte(stock)))
+		imported, err := ImportFstab(f)
+		assert.NoError(t, err)
+
+		configs := make([]*config.Config, 0, len(imported))
+		for _, i := range imported {
+			assert.Empty(t, i.Dropped, i.Line.GenerateFstabEntryString())
+			configs = append(configs, i.Config)
+		}
+		entries, err := RenderFstabLines(configs)
+		assert.NoError(t, err)
+		assert.Equal(t, stock, FormatDialectFstab(LinuxDialect{}, entries))
+	})
+}
@@ -0,0 +1,126 @@
+package fstab
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+
+	"tienbm90/yml2fstab/config"
+)
+
+// MergeResult is an existing fstab with the managed entries merged in.
+type MergeResult struct {
+	Lines []string
+	// Added entries had no line in the existing fstab
+	Added []*FstabLine
+	// Replaced are the existing lines of managed entries
+	Replaced []*FstabLine
+	// Removed are the existing lines matching an absent entry
+	Removed []*FstabLine
+}
+
+func (r *MergeResult) Content() string {
+	if len(r.Lines) == 0 {
+		return ""
+	}
+	return strings.Join(r.Lines, "\n") + "\n"
+}
+
+// matchesAbsent matches a line by mount point or device, swap lines by device and type. The
+// device is only compared when it names a single block device or remote export: tmpfs, none
+// or a bind source are shared by many lines.
+func matchesAbsent(line, absent *FstabLine) bool {
+	if absent.FileSystemType == "swap" {
+		return line.FileSystemType == "swap" && line.Device == absent.Device
+	}
+	if line.FileSystemType == "swap" {
+		return false
+	}
+	return line.MountPoint == absent.MountPoint || (line.Device == absent.Device && uniqueDevice(absent.Device))
+}
+
+// uniqueDevice reports whether device is a device tag, a /dev path or a remote source such as
+// server:/export or //server/share.
+func uniqueDevice(device string) bool {
+	return config.IsDeviceTag(device) || strings.HasPrefix(device, "/dev/") ||
+		strings.HasPrefix(device, "//") || strings.Contains(device, ":/")
+}
+
+// MergeFstab merges the managed entries into an existing fstab. Comments and unmanaged lines
+// are kept, lines of absent entries are removed even when they were never managed, lines of
+// managed entries are replaced in place and new entries are appended.
+func MergeFstab(existing string, entries []*FstabLine, absent []*FstabLine) (*MergeResult, error) {
+	managed := make(map[string][]*FstabLine)
+	for _, ent := range entries {
+		key := ent.Key()
+		managed[key] = append(managed[key], ent)
+	}
+
+	result := &MergeResult{Lines: make([]string, 0)}
+	written := make(map[*FstabLine]bool)
+	raw := strings.Split(strings.TrimRight(existing, "\n"), "\n")
+	if existing == "" {
+		raw = nil
+	}
+	for i, line := range raw {
+		ent, err := ParseFstabLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", i+1, err)
+		}
+		if ent == nil {
+			result.Lines = append(result.Lines, line)
+			continue
+		}
+
+		removed := false
+		for _, a := range absent {
+			if matchesAbsent(ent, a) {
+				removed = true
+				break
+			}
+		}
+		if removed {
+			result.Removed = append(result.Removed, ent)
+			continue
+		}
+
+		key := ent.Key()
+		if candidates := managed[key]; len(candidates) > 0 {
+			managed[key] = candidates[1:]
+			written[candidates[0]] = true
+			result.Replaced = append(result.Replaced, ent)
+			result.Lines = append(result.Lines, candidates[0].GenerateFstabEntryString())
+			continue
+		}
+		result.Lines = append(result.Lines, line)
+	}
+
+	for _, ent := range entries {
+		if !written[ent] {
+			result.Added = append(result.Added, ent)
+			result.Lines = append(result.Lines, ent.GenerateFstabEntryString())
+		}
+	}
+	return result, nil
+}
+
+// splitDiffLines splits s after every newline, difflib.SplitLines adds an empty last line.
+func splitDiffLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// UnifiedDiff returns the changes between two versions of path, empty when they are equal.
+func UnifiedDiff(path, before, after string) (string, error) {
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitDiffLines(before),
+		B:        splitDiffLines(after),
+		FromFile: path,
+		ToFile:   path,
+		Context:  3,
+	})
+}
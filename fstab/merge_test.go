@@ -0,0 +1,137 @@
+package fstab
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMergeFstab(t *testing.T) {
+	existing := `# manual entries
+/dev/sda2 / ext4 defaults 0 1
+192.168.4.5:/var/nfs/old /srv/old nfs defaults 0 0
+
+/dev/sdz1 /manual xfs defaults 0 0
+/dev/sda3 none swap sw 0 0
+/dev/sdy1 /legacy ext4 defaults 0 2
+`
+	entries := []*FstabLine{
+		NewFstabEntry("/dev/sda2", "/", "ext4", "noatime", 0, 1),
+		NewFstabEntry("/dev/sdb1", "/data", "xfs", "defaults", 0, 0),
+	}
+
+	t.Run("success", func(t *testing.T) {
+		absent := []*FstabLine{
+			// matched by device and mount point
+			NewFstabEntry("192.168.4.5:/var/nfs/old", "/srv/old", "nfs", "defaults", 0, 0),
+			NewFstabEntry("/dev/sdy1", "/legacy", "ext4", "noatime", 0, 2),
+			// matched by device, it is mounted elsewhere
+			NewFstabEntry("/dev/sdz1", "/srv/manual", "xfs", "defaults", 0, 0),
+			// swap is matched by device and type, the mount point is none or swap
+			NewFstabEntry("/dev/sda4", "swap", "swap", "defaults", 0, 0),
+		}
+		result, err := MergeFstab(existing, entries, absent)
+		assert.NoError(t, err)
+		assert.Equal(t, `# manual entries
+/dev/sda2 / ext4 noatime 0 1
+
+/dev/sda3 none swap sw 0 0
+/dev/sdb1 /data xfs defaults 0 0
+`, result.Content())
+		assert.Equal(t, []*FstabLine{entries[1]}, result.Added)
+		assert.Equal(t, []*FstabLine{NewFstabEntry("/dev/sda2", "/", "ext4", "defaults", 0, 1)}, result.Replaced)
+		assert.Equal(t, 3, len(result.Removed))
+		assert.Equal(t, "/srv/old", result.Removed[0].MountPoint)
+		assert.Equal(t, "/manual", result.Removed[1].MountPoint)
+		assert.Equal(t, "/legacy", result.Removed[2].MountPoint)
+
+		diff, err := UnifiedDiff("/etc/fstab", existing, result.Content())
+		assert.NoError(t, err)
+		assert.Equal(t, `--- /etc/fstab
++++ /etc/fstab
+@@ -1,7 +1,5 @@
+ # manual entries
+-/dev/sda2 / ext4 defaults 0 1
+-192.168.4.5:/var/nfs/old /srv/old nfs defaults 0 0
++/dev/sda2 / ext4 noatime 0 1
+ 
+-/dev/sdz1 /manual xfs defaults 0 0
+ /dev/sda3 none swap sw 0 0
+-/dev/sdy1 /legacy ext4 defaults 0 2
++/dev/sdb1 /data xfs defaults 0 0
+`, diff)
+	})
+
+	t.Run("idempotent", func(t *testing.T) {
+		first, err := MergeFstab(existing, entries, nil)
+		assert.NoError(t, err)
+		second, err := MergeFstab(first.Content(), entries, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, first.Content(), second.Content())
+		assert.Empty(t, second.Added)
+
+		diff, err := UnifiedDiff("/etc/fstab", first.Content(), second.Content())
+		assert.NoError(t, err)
+		assert.Equal(t, "", diff)
+	})
+
+	t.Run("empty fstab", func(t *testing.T) {
+		result, err := MergeFstab("", entries, entries[:1])
+		assert.NoError(t, err)
+		assert.Equal(t, "/dev/sda2 / ext4 noatime 0 1\n/dev/sdb1 /data xfs defaults 0 0\n", result.Content())
+		assert.Empty(t, result.Removed)
+	})
+
+	t.Run("absent entry sharing its device", func(t *testing.T) {
+		shared := `tmpfs /tmp tmpfs defaults 0 0
+tmpfs /run/user tmpfs size=10% 0 0
+/dev/sda3 none swap sw 0 0
+`
+		absent := []*FstabLine{
+			NewFstabEntry("tmpfs", "/run/user", "tmpfs", "defaults", 0, 0),
+			NewFstabEntry("/dev/sda3", "swap", "swap", "defaults", 0, 0),
+		}
+		result, err := MergeFstab(shared, nil, absent)
+		assert.NoError(t, err)
+		assert.Equal(t, "tmpfs /tmp tmpfs defaults 0 0\n", result.Content())
+		assert.Equal(t, 2, len(result.Removed))
+		assert.Equal(t, "/run/user", result.Removed[0].MountPoint)
+	})
+
+	t.Run("absent entry matched by mount point", func(t *testing.T) {
+		// the server is spelled differently from the existing line
+		absent := []*FstabLine{NewFstabEntry("nfs1.example.com:/var/nfs/old/", "/srv/old", "nfs", "defaults", 0, 0)}
+		result, err := MergeFstab(existing, nil, absent)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(result.Removed))
+		assert.Equal(t, "192.168.4.5:/var/nfs/old", result.Removed[0].Device)
+		assert.NotContains(t, result.Content(), "/srv/old")
+	})
+
+	t.Run("absent pseudo device", func(t *testing.T) {
+		shared := "tmpfs /tmp tmpfs defaults 0 0\nnone /sys/fs/bpf bpf defaults 0 0\n"
+		absent := []*FstabLine{
+			NewFstabEntry("tmpfs", "/scratch", "tmpfs", "defaults", 0, 0),
+			NewFstabEntry("none", "/sys/fs/pstore", "pstore", "defaults", 0, 0),
+		}
+		result, err := MergeFstab(shared, nil, absent)
+		assert.NoError(t, err)
+		assert.Equal(t, shared, result.Content())
+		assert.Empty(t, result.Removed)
+	})
+
+	t.Run("escaped fields", func(t *testing.T) {
+		spaced := []*FstabLine{NewFstabEntry("/dev/sdc1", "/srv/my data", "xfs", "noatime", 0, 0)}
+		result, err := MergeFstab("/dev/sdc1 /srv/my\\040data xfs defaults 0 0\n", spaced, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "/dev/sdc1 /srv/my\\040data xfs noatime 0 0\n", result.Content())
+		assert.Equal(t, 1, len(result.Replaced))
+
+		_, err = ParseFstab(result.Content())
+		assert.NoError(t, err)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := MergeFstab("/dev/sda2 /\n", entries, nil)
+		assert.Error(t, err)
+	})
+}
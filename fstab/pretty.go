@@ -0,0 +1,119 @@
+package fstab
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"tienbm90/yml2fstab/vfs"
+)
+
+// Version is the tool version written to the fstab header, set at build time with
+// -ldflags "-X tienbm90/yml2fstab/fstab.Version=v1.2.3".
+var Version = "dev"
+
+// prettyColumns are the titles of the commented column header.
+var prettyColumns = []string{"# device", "mount point", "type", "options", "dump", "pass"}
+
+// FstabHeader tells a reader of the written fstab where it came from.
+type FstabHeader struct {
+	Source    string
+	Checksum  string
+	Generated time.Time
+	Version   string
+}
+
+// NewFstabHeader returns the header of a fstab rendered from content, the YAML read from source.
+func NewFstabHeader(source string, content []byte, generated time.Time) *FstabHeader {
+	sum := sha256.Sum256(content)
+	return &FstabHeader{
+		Source:    source,
+		Checksum:  "sha256:" + hex.EncodeToString(sum[:]),
+		Generated: generated.UTC(),
+		Version:   Version,
+	}
+}
+
+func NewFstabHeaderFromFile(fsys vfs.FS, path string) (*FstabHeader, error) {
+	content, err := vfs.ReadFile(fsys, path)
+	if err != nil {
+		return nil, err
+	}
+	return NewFstabHeader(path, content, time.Now()), nil
+}
+
+func (h *FstabHeader) Lines() []string {
+	return []string{
+		fmt.Sprintf("# Generated by yml2fstab %s, do not edit by hand.", h.Version),
+		fmt.Sprintf("# Source:    %s", h.Source),
+		fmt.Sprintf("# Checksum:  %s", h.Checksum),
+		fmt.Sprintf("# Generated: %s", h.Generated.Format(time.RFC3339)),
+	}
+}
+
+// commentLines prefixes every line of a comment with "# " so it can span several lines.
+func commentLines(comment string) []string {
+	lines := make([]string, 0)
+	for _, l := range strings.Split(strings.TrimRight(comment, "\n"), "\n") {
+		lines = append(lines, strings.TrimRight("# "+l, " "))
+	}
+	return lines
+}
+
+// alignColumns pads every field to the width of its column, the last one is not padded.
+func alignColumns(rows [][]string) []string {
+	widths := make([]int, len(prettyColumns))
+	for _, row := range rows {
+		for i, field := range row {
+			if len(field) > widths[i] {
+				widths[i] = len(field)
+			}
+		}
+	}
+	lines := make([]string, 0, len(rows))
+	for _, row := range rows {
+		var b strings.Builder
+		for i, field := range row {
+			if i == len(row)-1 {
+				b.WriteString(field)
+				break
+			}
+			b.WriteString(field)
+			b.WriteString(strings.Repeat(" ", widths[i]-len(field)+1))
+		}
+		lines = append(lines, b.String())
+	}
+	return lines
+}
+
+// FormatPrettyFstab renders the header, a commented column header and the entries in aligned
+// columns, each preceded by its comment. The fields are the ones of FstabLineFields,
+// only the whitespace between them differs.
+func FormatPrettyFstab(header *FstabHeader, entries []*FstabLine) string {
+	rows := [][]string{prettyColumns}
+	for _, ent := range entries {
+		rows = append(rows, FstabLineFields(ent))
+	}
+	aligned := alignColumns(rows)
+
+	lines := make([]string, 0)
+	if header != nil {
+		lines = append(lines, header.Lines()...)
+		lines = append(lines, "#")
+	}
+	lines = append(lines, aligned[0])
+	for i, ent := range entries {
+		if ent.Comment != "" {
+			lines = append(lines, "")
+			lines = append(lines, commentLines(ent.Comment)...)
+		}
+		lines = append(lines, aligned[i+1])
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func WritePrettyFstabFileContentToTempFile(fsys vfs.FS, header *FstabHeader, entries []*FstabLine, dst string) error {
+	return WriteFile(fsys, dst, []byte(FormatPrettyFstab(header, entries)))
+}
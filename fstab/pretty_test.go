@@ -0,0 +1,106 @@
+package fstab
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+
+	"tienbm90/yml2fstab/config"
+	"tienbm90/yml2fstab/vfs"
+)
+
+func TestNewFstabHeader(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		generated := time.Date(2026, 10, 19, 8, 30, 0, 0, time.FixedZone("CEST", 2*3600))
+		header := NewFstabHeader("input.yml", []byte("fstab: {}\n"), generated)
+		assert.Equal(t, "input.yml", header.Source)
+		assert.True(t, strings.HasPrefix(header.Checksum, "sha256:"))
+		assert.Equal(t, len("sha256:")+64, len(header.Checksum))
+		assert.Equal(t, []string{
+			"# Generated by yml2fstab dev, do not edit by hand.",
+			"# Source:    input.yml",
+			"# Checksum:  " + header.Checksum,
+			"# Generated: 2026-10-19T06:30:00Z",
+		}, header.Lines())
+
+		other := NewFstabHeader("input.yml", []byte("fstab: {}\n\n"), generated)
+		assert.NotEqual(t, header.Checksum, other.Checksum)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := NewFstabHeaderFromFile(vfs.NewMemoryFS(), "/etc/yml2fstab/missing.yml")
+		assert.Error(t, err)
+	})
+}
+
+func TestFormatPrettyFstab(t *testing.T) {
+	entries := []*FstabLine{
+		NewFstabEntry("UUID=5e1a", "/", "ext4", "defaults", 0, 1),
+		NewFstabLineWithOptions(WithDevice("192.168.4.5:/var/nfs/home"), WithMountPoint("/home"), WithFileSystemType("nfs"),
+			WithOptions("noexec,nosuid"), WithComment("home directories\nowned by the storage team")),
+		NewFstabEntry("/dev/sda3", "swap", "swap", "defaults", 0, 0),
+		NewFstabEntry("/dev/sdc1", "/srv/my data", "xfs", "defaults", 0, 0),
+	}
+
+	t.Run("success", func(t *testing.T) {
+		header := &FstabHeader{Source: "input.yml", Checksum: "sha256:00", Generated: time.Date(2026, 10, 19, 6, 30, 0, 0, time.UTC), Version: "v1.0.0"}
+		assert.Equal(t, `# Generated by yml2fstab v1.0.0, do not edit by hand.
+# Source:    input.yml
+# Checksum:  sha256:00
+# Generated: 2026-10-19T06:30:00Z
+#
+# device                  mount point     type options       dump pass
+UUID=5e1a                 /               ext4 defaults      0    1
+
+# home directories
+# owned by the storage team
+192.168.4.5:/var/nfs/home /home           nfs  noexec,nosuid 0    0
+/dev/sda3                 swap            swap defaults      0    0
+/dev/sdc1                 /srv/my\040data xfs  defaults      0    0
+`, FormatPrettyFstab(header, entries))
+	})
+
+	t.Run("parses identically", func(t *testing.T) {
+		content := FormatPrettyFstab(NewFstabHeader("input.yml", nil, time.Now()), entries)
+		parsed := make([][]string, 0)
+		for _, line := range strings.Split(content, "\n") {
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			parsed = append(parsed, strings.Fields(line))
+		}
+		assert.Equal(t, len(entries), len(parsed))
+		for i, ent := range entries {
+			assert.Equal(t, strings.Fields(ent.GenerateFstabEntryString()), parsed[i])
+		}
+	})
+
+	t.Run("write", func(t *testing.T) {
+		fsys := vfs.NewMemoryFS()
+		assert.NoError(t, fsys.MkdirAll("/etc", 0755))
+		assert.NoError(t, WritePrettyFstabFileContentToTempFile(fsys, nil, entries, "/etc/fstab"))
+		content, err := vfs.ReadFile(fsys, "/etc/fstab")
+		assert.NoError(t, err)
+		assert.Equal(t, FormatPrettyFstab(nil, entries), string(content))
+	})
+}
+
+func TestConfigComment(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		cnf, err := config.NewConfigFromMapData("/dev/sdb1", map[string]interface{}{"mount": "/data", "type": "ext4", "comment": "scratch disk"})
+		assert.NoError(t, err)
+		ent, err := RenderFstabLine(cnf)
+		assert.NoError(t, err)
+		assert.Equal(t, "scratch disk", ent.Comment)
+
+		swap, err := config.NewSwapConfigFromMapData("/dev/sda3", map[string]interface{}{"comment": "swap partition"})
+		assert.NoError(t, err)
+		assert.Equal(t, "swap partition", swap.Comment)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := config.NewConfigFromMapData("/dev/sdb1", map[string]interface{}{"mount": "/data", "type": "ext4", "comment": 42})
+		assert.Error(t, err)
+	})
+}
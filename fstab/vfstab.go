@@ -0,0 +1,150 @@
+package fstab
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"tienbm90/yml2fstab/config"
+)
+
+const (
+	FormatVfstab = "vfstab"
+
+	// VfstabNone is the placeholder of an empty vfstab column.
+	VfstabNone = "-"
+
+	vfstabBlockDir = "/dev/dsk/"
+	vfstabRawDir   = "/dev/rdsk/"
+)
+
+// VfstabFileSystemTypes maps Linux types to their Solaris/illumos names.
+var VfstabFileSystemTypes = map[string]string{
+	"ufs":     "ufs",
+	"nfs":     "nfs",
+	"nfs4":    "nfs",
+	"tmpfs":   "tmpfs",
+	"vfat":    "pcfs",
+	"iso9660": "hsfs",
+	"udf":     "udf",
+	"swap":    "swap",
+}
+
+// VfstabUnsupportedOptions are Linux mount options mount(1M) does not understand.
+var VfstabUnsupportedOptions = []string{"nofail", "user", "users", "owner", "group", "errors",
+	"relatime", "strictatime", "lazytime", "nodiratime", "comment"}
+
+// VfstabDialect renders the seven column vfstab(4) format:
+// device to mount, device to fsck, mount point, FS type, fsck pass, mount at boot, options.
+// The converted line keeps noauto in its options, the mount at boot column is derived from it.
+type VfstabDialect struct{}
+
+func (VfstabDialect) Name() string {
+	return FormatVfstab
+}
+
+func (VfstabDialect) ConvertLine(line *FstabLine) (*FstabLine, error) {
+	fsType, ok := VfstabFileSystemTypes[line.FileSystemType]
+	if !ok {
+		return nil, fmt.Errorf("filesystem type %s has no vfstab equivalent", line.FileSystemType)
+	}
+	if config.IsDeviceTag(line.Device) {
+		return nil, fmt.Errorf("device tag %s is not supported", line.Device)
+	}
+	// vfstab has no escapes, a space would split the field
+	if strings.ContainsAny(line.Device+line.MountPoint, " \t\n") {
+		return nil, errors.New("whitespace in the device or mount point is not supported")
+	}
+
+	options := make([]string, 0)
+	for _, opt := range SplitOptions(line.Options) {
+		key := OptionKey(opt)
+		if strings.HasPrefix(key, SystemdOptionPrefix) {
+			return nil, fmt.Errorf("option %s is systemd specific", opt)
+		}
+		for _, unsupported := range VfstabUnsupportedOptions {
+			if key == unsupported {
+				return nil, fmt.Errorf("option %s is not supported", opt)
+			}
+		}
+		switch {
+		case key == "_netdev":
+			// nfs entries are mounted after the network anyway
+		case fsType == "swap" && key == "pri", fsType == "swap" && key == "discard":
+			return nil, fmt.Errorf("swap option %s is not supported", opt)
+		default:
+			options = append(options, opt)
+		}
+	}
+	if line.FileSystemType == "nfs4" {
+		options = append(options, "vers=4")
+	}
+
+	mountPoint := line.MountPoint
+	if fsType == "swap" {
+		mountPoint = VfstabNone
+	}
+
+	return NewFstabLineWithOptions(
+		WithDevice(line.Device),
+		WithMountPoint(mountPoint),
+		WithFileSystemType(fsType),
+		WithOptions(config.BuildStringFromSlice(options)),
+		WithBackupOperation(0),
+		WithFileSystemCheckOrder(line.FileSystemCheckOrder),
+	), nil
+}
+
+// VfstabFsckDevice returns the raw device fsck checks, only ufs on a /dev/dsk slice has one.
+func VfstabFsckDevice(line *FstabLine) string {
+	if line.FileSystemType != "ufs" || !strings.HasPrefix(line.Device, vfstabBlockDir) {
+		return VfstabNone
+	}
+	return vfstabRawDir + strings.TrimPrefix(line.Device, vfstabBlockDir)
+}
+
+// VfstabMountAtBoot is "no" for noauto entries, swap and the root filesystem mounted by the kernel.
+func VfstabMountAtBoot(line *FstabLine) string {
+	if line.FileSystemType == "swap" || line.MountPoint == "/" {
+		return "no"
+	}
+	for _, opt := range strings.Split(line.Options, ",") {
+		if opt == "noauto" {
+			return "no"
+		}
+	}
+	return "yes"
+}
+
+func (VfstabDialect) FormatLine(line *FstabLine) string {
+	pass := VfstabNone
+	if line.FileSystemCheckOrder > 0 {
+		pass = strconv.Itoa(line.FileSystemCheckOrder)
+	}
+
+	options := make([]string, 0)
+	for _, opt := range SplitOptions(line.Options) {
+		if opt != "noauto" {
+			options = append(options, opt)
+		}
+	}
+	opts := config.BuildStringFromSlice(options)
+	if opts == "" {
+		opts = VfstabNone
+	}
+
+	return strings.Join([]string{
+		line.Device,
+		VfstabFsckDevice(line),
+		line.MountPoint,
+		line.FileSystemType,
+		pass,
+		VfstabMountAtBoot(line),
+		opts,
+	}, "\t")
+}
+
+func init() {
+	RegisterDialect(VfstabDialect{})
+}
@@ -0,0 +1,65 @@
+package fstab
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+
+	"tienbm90/yml2fstab/config"
+)
+
+func TestVfstabDialect(t *testing.T) {
+	d, ok := LookupDialect(FormatVfstab)
+	assert.True(t, ok)
+
+	t.Run("success", func(t *testing.T) {
+		data := []struct {
+			Line     *FstabLine
+			Expected string
+		}{
+			{
+				Line:     NewFstabEntry("/dev/dsk/c0t0d0s0", "/", "ufs", "defaults", 0, 1),
+				Expected: "/dev/dsk/c0t0d0s0\t/dev/rdsk/c0t0d0s0\t/\tufs\t1\tno\t-",
+			},
+			{
+				Line:     NewFstabEntry("/dev/dsk/c0t0d0s7", "/export/home", "ufs", "logging,nosuid", 0, 2),
+				Expected: "/dev/dsk/c0t0d0s7\t/dev/rdsk/c0t0d0s7\t/export/home\tufs\t2\tyes\tlogging,nosuid",
+			},
+			{
+				Line:     NewFstabEntry("192.168.4.5:/var/nfs/home", "/home", "nfs", "noexec,nosuid,_netdev", 0, 0),
+				Expected: "192.168.4.5:/var/nfs/home\t-\t/home\tnfs\t-\tyes\tnoexec,nosuid",
+			},
+			{
+				Line:     NewFstabEntry("192.168.4.5:/archive", "/archive", "nfs4", "noauto,ro", 0, 0),
+				Expected: "192.168.4.5:/archive\t-\t/archive\tnfs\t-\tno\tro,vers=4",
+			},
+			{
+				Line:     NewFstabEntry("/dev/dsk/c0t0d0s1", config.SwapMountPoint, "swap", "defaults", 0, 0),
+				Expected: "/dev/dsk/c0t0d0s1\t-\t-\tswap\t-\tno\t-",
+			},
+			{
+				Line:     NewFstabEntry("swap", "/tmp", "tmpfs", "size=512m", 0, 0),
+				Expected: "swap\t-\t/tmp\ttmpfs\t-\tyes\tsize=512m",
+			},
+		}
+		for _, data := range data {
+			line, err := d.ConvertLine(data.Line)
+			assert.NoError(t, err)
+			assert.Equal(t, data.Expected, d.FormatLine(line))
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		invalid := []*FstabLine{
+			NewFstabEntry("/dev/sda1", "/boot", "xfs", "defaults", 0, 0),
+			NewFstabEntry("UUID=0a34", "/data", "ufs", "defaults", 0, 2),
+			NewFstabEntry("/dev/dsk/c0t1d0s0", "/data", "ufs", "nofail", 0, 2),
+			NewFstabEntry("/dev/dsk/c0t1d0s0", "/data", "ufs", "x-systemd.automount", 0, 2),
+			NewFstabEntry("/dev/dsk/c0t0d0s1", config.SwapMountPoint, "swap", "pri=1", 0, 0),
+			NewFstabEntry("/dev/dsk/c0t1d0s0", "/export/my data", "ufs", "defaults", 0, 2),
+		}
+		for _, line := range invalid {
+			_, err := d.ConvertLine(line)
+			assert.Error(t, err, line.GenerateFstabEntryString())
+		}
+	})
+}
@@ -3,11 +3,9 @@ module tienbm90/yml2fstab
 go 1.17
 
 require (
+	github.com/pmezard/go-difflib v1.0.0
 	github.com/stretchr/testify v1.7.0
 	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b
 )
 
-require (
-	github.com/davecgh/go-spew v1.1.0 // indirect
-	github.com/pmezard/go-difflib v1.0.0 // indirect
-)
+require github.com/davecgh/go-spew v1.1.0 // indirect
@@ -0,0 +1,5 @@
+// Package mount reads the mount table of the running system and reconciles it with
+// the desired state of the configs through a Mounter.
+//
+// Mounting is only supported on linux, elsewhere the SyscallMounter always fails.
+package mount
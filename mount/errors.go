@@ -0,0 +1,28 @@
+package mount
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrMount is matched by every failed mount, unmount or remount.
+var ErrMount = errors.New("mount error")
+
+// MountError is a failed action on MountPoint, Err is the cause.
+type MountError struct {
+	Action     string
+	MountPoint string
+	Err        error
+}
+
+func (e *MountError) Error() string {
+	return fmt.Sprintf("%s %s: %s", e.Action, e.MountPoint, e.Err)
+}
+
+func (e *MountError) Unwrap() error {
+	return e.Err
+}
+
+func (e *MountError) Is(target error) bool {
+	return target == ErrMount
+}
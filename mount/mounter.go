@@ -0,0 +1,113 @@
+package mount
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"tienbm90/yml2fstab/config"
+	"tienbm90/yml2fstab/fstab"
+)
+
+// Mounter changes the mounts of the running system.
+type Mounter interface {
+	Mount(ent *fstab.FstabLine) error
+	Unmount(mountPoint string) error
+	// Remount applies the options of ent to its mounted filesystem.
+	Remount(ent *fstab.FstabLine) error
+}
+
+// MemoryMounter is a Mounter keeping its mounts in memory, it records every call.
+type MemoryMounter struct {
+	mounts map[string]*fstab.FstabLine
+	Calls  []string
+}
+
+func NewMemoryMounter(mounted ...*fstab.FstabLine) *MemoryMounter {
+	m := &MemoryMounter{mounts: make(map[string]*fstab.FstabLine)}
+	for _, ent := range mounted {
+		m.mounts[ent.MountPoint] = ent
+	}
+	return m
+}
+
+func (m *MemoryMounter) Mount(ent *fstab.FstabLine) error {
+	m.Calls = append(m.Calls, "mount "+ent.MountPoint)
+	if _, ok := m.mounts[ent.MountPoint]; ok {
+		return fmt.Errorf("%s is already mounted", ent.MountPoint)
+	}
+	m.mounts[ent.MountPoint] = ent
+	return nil
+}
+
+func (m *MemoryMounter) Unmount(mountPoint string) error {
+	m.Calls = append(m.Calls, "unmount "+mountPoint)
+	if _, ok := m.mounts[mountPoint]; !ok {
+		return fmt.Errorf("%s is not mounted", mountPoint)
+	}
+	delete(m.mounts, mountPoint)
+	return nil
+}
+
+func (m *MemoryMounter) Remount(ent *fstab.FstabLine) error {
+	m.Calls = append(m.Calls, "remount "+ent.MountPoint)
+	mounted, ok := m.mounts[ent.MountPoint]
+	if !ok {
+		return fmt.Errorf("%s is not mounted", ent.MountPoint)
+	}
+	remounted := *mounted
+	remounted.Options = ent.Options
+	m.mounts[ent.MountPoint] = &remounted
+	return nil
+}
+
+// MountedFileSystems returns the mounts the way mountinfo would list them, sorted by mount point.
+func (m *MemoryMounter) MountedFileSystems() []*MountedFileSystem {
+	mounts := make([]*MountedFileSystem, 0, len(m.mounts))
+	for _, ent := range m.mounts {
+		options := fstab.SplitOptions(ent.Options)
+		if !containsOption(options, "ro") {
+			options = append([]string{"rw"}, options...)
+		}
+		mounts = append(mounts, &MountedFileSystem{
+			Source:       ent.Device,
+			MountPoint:   ent.MountPoint,
+			Type:         ent.FileSystemType,
+			MountOptions: options,
+		})
+	}
+	sort.Slice(mounts, func(i, j int) bool {
+		return mounts[i].MountPoint < mounts[j].MountPoint
+	})
+	return mounts
+}
+
+func containsOption(options []string, opt string) bool {
+	for _, o := range options {
+		if o == opt {
+			return true
+		}
+	}
+	return false
+}
+
+// fstabOnlyOptions are read by mount(8) and the boot, not by the kernel.
+var fstabOnlyOptions = []string{"defaults", "auto", "noauto", "nofail", "_netdev", "user", "users", "nouser", "owner", "group"}
+
+// SplitMountOptions splits the options of ent into the VFS flags of MountFlagOptions and the
+// filesystem specific data passed to the kernel, dropping the options only fstab reads.
+func SplitMountOptions(options string) ([]string, string) {
+	flags := make([]string, 0)
+	data := make([]string, 0)
+	for _, opt := range fstab.SplitOptions(options) {
+		key := fstab.OptionKey(opt)
+		switch {
+		case containsOption(fstabOnlyOptions, key), strings.HasPrefix(key, "x-"), key == "comment":
+		case containsOption(MountFlagOptions, opt), containsOption(mountClearFlagOptions, opt):
+			flags = append(flags, opt)
+		default:
+			data = append(data, opt)
+		}
+	}
+	return flags, config.BuildStringFromSlice(data)
+}
@@ -0,0 +1,88 @@
+//go:build linux
+// +build linux
+
+package mount
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"syscall"
+
+	"tienbm90/yml2fstab/config"
+	"tienbm90/yml2fstab/fstab"
+	"tienbm90/yml2fstab/vfs"
+)
+
+var mountFlags = map[string]uintptr{
+	"ro":          syscall.MS_RDONLY,
+	"nosuid":      syscall.MS_NOSUID,
+	"nodev":       syscall.MS_NODEV,
+	"noexec":      syscall.MS_NOEXEC,
+	"sync":        syscall.MS_SYNCHRONOUS,
+	"dirsync":     syscall.MS_DIRSYNC,
+	"noatime":     syscall.MS_NOATIME,
+	"nodiratime":  syscall.MS_NODIRATIME,
+	"relatime":    syscall.MS_RELATIME,
+	"strictatime": syscall.MS_STRICTATIME,
+}
+
+// SyscallMounter mounts with mount(2) and umount(2), without the mount(8) helpers. The
+// missing mount points are created in fsys.
+type SyscallMounter struct {
+	fsys vfs.FS
+}
+
+func NewSyscallMounter(fsys vfs.FS) Mounter {
+	return SyscallMounter{fsys: fsys}
+}
+
+func mountFlagsAndData(ent *fstab.FstabLine) (uintptr, string, error) {
+	var flags uintptr
+	options, data := SplitMountOptions(ent.Options)
+	for _, opt := range options {
+		flags |= mountFlags[opt]
+	}
+	// the kernel nfs client wants the server address, mount.nfs resolves it otherwise
+	if sameMountType(ent.FileSystemType, "nfs") && !strings.Contains(","+data, ",addr=") {
+		server := strings.SplitN(ent.Device, ":", 2)[0]
+		addrs, err := net.LookupHost(server)
+		if err != nil {
+			return 0, "", err
+		}
+		data = config.BuildStringFromSlice(append(fstab.SplitOptions(data), "addr="+addrs[0]))
+	}
+	return flags, data, nil
+}
+
+func (m SyscallMounter) Mount(ent *fstab.FstabLine) error {
+	flags, data, err := mountFlagsAndData(ent)
+	if err != nil {
+		return err
+	}
+	if err := m.fsys.MkdirAll(ent.MountPoint, 0755); err != nil {
+		return err
+	}
+	if err := syscall.Mount(config.DeviceTagToPath(ent.Device), ent.MountPoint, ent.FileSystemType, flags, data); err != nil {
+		return fmt.Errorf("mount %s on %s: %w", ent.Device, ent.MountPoint, err)
+	}
+	return nil
+}
+
+func (SyscallMounter) Unmount(mountPoint string) error {
+	if err := syscall.Unmount(mountPoint, 0); err != nil {
+		return fmt.Errorf("unmount %s: %w", mountPoint, err)
+	}
+	return nil
+}
+
+func (SyscallMounter) Remount(ent *fstab.FstabLine) error {
+	flags, data, err := mountFlagsAndData(ent)
+	if err != nil {
+		return err
+	}
+	if err := syscall.Mount(config.DeviceTagToPath(ent.Device), ent.MountPoint, ent.FileSystemType, flags|syscall.MS_REMOUNT, data); err != nil {
+		return fmt.Errorf("remount %s: %w", ent.MountPoint, err)
+	}
+	return nil
+}
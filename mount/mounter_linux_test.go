@@ -0,0 +1,25 @@
+//go:build linux
+// +build linux
+
+package mount
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"syscall"
+	"testing"
+
+	"tienbm90/yml2fstab/vfs"
+)
+
+func TestSyscallMounter(t *testing.T) {
+	t.Run("errno kept", func(t *testing.T) {
+		// not a mount point, EINVAL as root and EPERM otherwise
+		actions := []MountAction{{Action: MountActionUnmount, MountPoint: t.TempDir()}}
+		_, err := ApplyMountActions(NewSyscallMounter(vfs.NewMemoryFS()), actions)
+		assert.True(t, errors.Is(err, ErrMount))
+		var errno syscall.Errno
+		assert.True(t, errors.As(err, &errno))
+		assert.True(t, errors.Is(err, syscall.EINVAL) || errors.Is(err, syscall.EPERM))
+	})
+}
@@ -0,0 +1,31 @@
+//go:build !linux
+// +build !linux
+
+package mount
+
+import (
+	"errors"
+
+	"tienbm90/yml2fstab/fstab"
+	"tienbm90/yml2fstab/vfs"
+)
+
+var errMountNotSupported = errors.New("mounting is only supported on linux")
+
+type SyscallMounter struct{}
+
+func NewSyscallMounter(fsys vfs.FS) Mounter {
+	return SyscallMounter{}
+}
+
+func (SyscallMounter) Mount(ent *fstab.FstabLine) error {
+	return errMountNotSupported
+}
+
+func (SyscallMounter) Unmount(mountPoint string) error {
+	return errMountNotSupported
+}
+
+func (SyscallMounter) Remount(ent *fstab.FstabLine) error {
+	return errMountNotSupported
+}
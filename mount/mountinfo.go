@@ -0,0 +1,194 @@
+package mount
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"tienbm90/yml2fstab/fstab"
+	"tienbm90/yml2fstab/vfs"
+)
+
+// DefaultMountInfoPath lists the mounts of the calling process.
+const DefaultMountInfoPath = "/proc/self/mountinfo"
+
+// mountInfoSeparator ends the optional fields of a mountinfo line.
+const mountInfoSeparator = "-"
+
+// MountPropagation holds the propagation tags of the optional fields, a peer group of 0
+// means the tag is not set.
+type MountPropagation struct {
+	// shared:N, the mount is shared in peer group N
+	Shared int
+	// master:N, the mount is a slave of peer group N
+	Master int
+	// propagate_from:N, the closest dominant peer group visible to the process
+	PropagateFrom int
+	Unbindable    bool
+}
+
+func (p MountPropagation) IsPrivate() bool {
+	return p.Shared == 0 && p.Master == 0 && !p.Unbindable
+}
+
+// MountedFileSystem is a mount of the running system, a line of proc(5) mountinfo.
+type MountedFileSystem struct {
+	ID       int
+	ParentID int
+	Major    int
+	Minor    int
+	// root of the mount within the filesystem, not / for bind mounts
+	Root       string
+	MountPoint string
+	// per mount options
+	MountOptions []string
+	// optional fields as written, such as shared:1
+	OptionalFields []string
+	Propagation    MountPropagation
+	Type           string
+	Source         string
+	// options of the super block, shared by every mount of the filesystem
+	SuperOptions []string
+}
+
+// Options returns the per mount options followed by the super block options.
+func (m *MountedFileSystem) Options() []string {
+	options := make([]string, 0, len(m.MountOptions)+len(m.SuperOptions))
+	return append(append(options, m.MountOptions...), m.SuperOptions...)
+}
+
+// HasOption looks up the VFS flags and rw in the per mount options, a read-only bind mount
+// of a read-write filesystem has rw in its super options. Other options may be in either.
+func (m *MountedFileSystem) HasOption(opt string) bool {
+	if opt == "rw" || containsOption(MountFlagOptions, opt) {
+		return containsOption(m.MountOptions, opt)
+	}
+	return containsOption(m.MountOptions, opt) || containsOption(m.SuperOptions, opt)
+}
+
+func parseMountPropagation(fields []string) (MountPropagation, error) {
+	var p MountPropagation
+	for _, field := range fields {
+		if field == "unbindable" {
+			p.Unbindable = true
+			continue
+		}
+		tag := strings.SplitN(field, ":", 2)
+		if len(tag) != 2 {
+			// fields added by later kernels are skipped
+			continue
+		}
+		group, err := strconv.Atoi(tag[1])
+		if err != nil {
+			return p, fmt.Errorf("invalid peer group in %s", field)
+		}
+		switch tag[0] {
+		case "shared":
+			p.Shared = group
+		case "master":
+			p.Master = group
+		case "propagate_from":
+			p.PropagateFrom = group
+		}
+	}
+	return p, nil
+}
+
+func splitMountInfoOptions(options string) []string {
+	if options == "" {
+		return []string{}
+	}
+	return strings.Split(options, ",")
+}
+
+// ParseMountInfoLine parses a line of proc(5) mountinfo:
+// 36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 - ext3 /dev/root rw,errors=continue
+// Fields are separated by single spaces, paths escape spaces and tabs as octal.
+func ParseMountInfoLine(line string) (*MountedFileSystem, error) {
+	fields := strings.Split(strings.TrimRight(line, "\n"), " ")
+	sep := -1
+	for i := 6; i < len(fields); i++ {
+		if fields[i] == mountInfoSeparator {
+			sep = i
+			break
+		}
+	}
+	// the super options may be missing on old kernels
+	if sep < 0 || sep+3 > len(fields) || sep+4 < len(fields) {
+		return nil, fmt.Errorf("invalid mountinfo line %q", line)
+	}
+
+	ids := make([]int, 0, 4)
+	device := strings.SplitN(fields[2], ":", 2)
+	if len(device) != 2 {
+		return nil, fmt.Errorf("invalid device %s in mountinfo line %q", fields[2], line)
+	}
+	for _, f := range []string{fields[0], fields[1], device[0], device[1]} {
+		n, err := strconv.Atoi(f)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %s in mountinfo line %q", f, line)
+		}
+		ids = append(ids, n)
+	}
+
+	optional := fields[6:sep]
+	propagation, err := parseMountPropagation(optional)
+	if err != nil {
+		return nil, fmt.Errorf("%s in mountinfo line %q", err, line)
+	}
+	superOptions := []string{}
+	if sep+3 < len(fields) {
+		superOptions = splitMountInfoOptions(fields[sep+3])
+	}
+
+	return &MountedFileSystem{
+		ID:             ids[0],
+		ParentID:       ids[1],
+		Major:          ids[2],
+		Minor:          ids[3],
+		Root:           fstab.UnescapeFstabField(fields[3]),
+		MountPoint:     fstab.UnescapeFstabField(fields[4]),
+		MountOptions:   splitMountInfoOptions(fields[5]),
+		OptionalFields: append([]string{}, optional...),
+		Propagation:    propagation,
+		Type:           fields[sep+1],
+		Source:         fstab.UnescapeFstabField(fields[sep+2]),
+		SuperOptions:   superOptions,
+	}, nil
+}
+
+func ParseMountInfo(content string) ([]*MountedFileSystem, error) {
+	mounts := make([]*MountedFileSystem, 0)
+	for i, line := range strings.Split(content, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		m, err := ParseMountInfoLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", i+1, err)
+		}
+		mounts = append(mounts, m)
+	}
+	return mounts, nil
+}
+
+// ReadMountInfo parses the mountinfo file at path of fsys.
+func ReadMountInfo(fsys vfs.FS, path string) ([]*MountedFileSystem, error) {
+	content, err := vfs.ReadFile(fsys, path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseMountInfo(string(content))
+}
+
+// FindMountedFileSystem returns the mount visible at mountPoint, the last one listed when
+// several are stacked.
+func FindMountedFileSystem(mounted []*MountedFileSystem, mountPoint string) (*MountedFileSystem, bool) {
+	var found *MountedFileSystem
+	for _, m := range mounted {
+		if m.MountPoint == mountPoint {
+			found = m
+		}
+	}
+	return found, found != nil
+}
@@ -0,0 +1,106 @@
+package mount
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"syscall"
+	"testing"
+
+	"tienbm90/yml2fstab/vfs"
+)
+
+const testMountInfo = `22 1 8:2 / / rw,relatime shared:1 - ext4 /dev/sda2 rw
+36 22 8:17 / /var/lib/my\040data rw,nosuid,nodev shared:20 master:3 - xfs /dev/sdb1 rw,attr2,inode64
+41 22 0:45 / /home rw,relatime - nfs4 192.168.4.5:/var/nfs/home rw,vers=4.2,addr=192.168.4.5
+52 22 8:17 /exports/www /srv/www ro,relatime master:20 propagate_from:1 unbindable - xfs /dev/sdb1 rw,attr2
+`
+
+func TestParseMountInfo(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mounts, err := ParseMountInfo(testMountInfo)
+		assert.NoError(t, err)
+		assert.Equal(t, 4, len(mounts))
+		assert.Equal(t, &MountedFileSystem{
+			ID:             36,
+			ParentID:       22,
+			Major:          8,
+			Minor:          17,
+			Root:           "/",
+			MountPoint:     "/var/lib/my data",
+			MountOptions:   []string{"rw", "nosuid", "nodev"},
+			OptionalFields: []string{"shared:20", "master:3"},
+			Propagation:    MountPropagation{Shared: 20, Master: 3},
+			Type:           "xfs",
+			Source:         "/dev/sdb1",
+			SuperOptions:   []string{"rw", "attr2", "inode64"},
+		}, mounts[1])
+		assert.True(t, mounts[1].HasOption("nodev"))
+		assert.True(t, mounts[1].HasOption("inode64"))
+		assert.False(t, mounts[1].HasOption("noexec"))
+		assert.Equal(t, []string{"rw", "nosuid", "nodev", "rw", "attr2", "inode64"}, mounts[1].Options())
+
+		assert.Equal(t, []string{}, mounts[2].OptionalFields)
+		assert.True(t, mounts[2].Propagation.IsPrivate())
+		assert.Equal(t, "192.168.4.5:/var/nfs/home", mounts[2].Source)
+
+		bind := mounts[3]
+		assert.Equal(t, "/exports/www", bind.Root)
+		assert.Equal(t, MountPropagation{Master: 20, PropagateFrom: 1, Unbindable: true}, bind.Propagation)
+		assert.False(t, bind.Propagation.IsPrivate())
+	})
+
+	t.Run("missing super options", func(t *testing.T) {
+		m, err := ParseMountInfoLine("22 1 8:2 / / rw - ext4 /dev/sda2")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{}, m.SuperOptions)
+	})
+
+	t.Run("escaped source", func(t *testing.T) {
+		m, err := ParseMountInfoLine(`60 22 0:50 / /mnt/a\011b rw - fuse.sshfs user@host:/my\040dir rw,user_id=0`)
+		assert.NoError(t, err)
+		assert.Equal(t, "/mnt/a\tb", m.MountPoint)
+		assert.Equal(t, "fuse.sshfs", m.Type)
+		assert.Equal(t, "user@host:/my dir", m.Source)
+	})
+
+	t.Run("find", func(t *testing.T) {
+		mounts, err := ParseMountInfo(testMountInfo + "70 36 0:60 / /home rw - tmpfs tmpfs rw\n")
+		assert.NoError(t, err)
+		m, ok := FindMountedFileSystem(mounts, "/home")
+		assert.True(t, ok)
+		assert.Equal(t, "tmpfs", m.Type)
+		_, ok = FindMountedFileSystem(mounts, "/srv")
+		assert.False(t, ok)
+	})
+
+	t.Run("read", func(t *testing.T) {
+		fsys := vfs.NewMemoryFS()
+		assert.NoError(t, fsys.MkdirAll("/proc/self", 0555))
+		assert.NoError(t, vfs.WriteFile(fsys, DefaultMountInfoPath, []byte(testMountInfo), 0444))
+		mounts, err := ReadMountInfo(fsys, DefaultMountInfoPath)
+		assert.NoError(t, err)
+		assert.Equal(t, 4, len(mounts))
+
+		_, err = ReadMountInfo(fsys, "/proc/1/mountinfo")
+		assert.True(t, os.IsNotExist(err))
+
+		fsys.Fail(vfs.OpRead, DefaultMountInfoPath, syscall.EIO)
+		_, err = ReadMountInfo(fsys, DefaultMountInfoPath)
+		assert.True(t, errors.Is(err, syscall.EIO))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, line := range []string{
+			"22 1 8:2 / / rw,relatime shared:1 ext4 /dev/sda2 rw",
+			"22 1 8:2 / / rw - ext4",
+			"22 1 8:2 / / rw - ext4 /dev/sda2 rw extra",
+			"a 1 8:2 / / rw - ext4 /dev/sda2 rw",
+			"22 1 82 / / rw - ext4 /dev/sda2 rw",
+			"22 1 8:2 / / rw shared:x - ext4 /dev/sda2 rw",
+		} {
+			_, err := ParseMountInfoLine(line)
+			assert.Error(t, err, line)
+		}
+	})
+}
@@ -0,0 +1,174 @@
+package mount
+
+import (
+	"fmt"
+	"sort"
+
+	"tienbm90/yml2fstab/config"
+	"tienbm90/yml2fstab/fstab"
+	"tienbm90/yml2fstab/vfs"
+)
+
+const (
+	MountActionMount   = "mount"
+	MountActionUnmount = "unmount"
+	MountActionRemount = "remount"
+)
+
+// MountFlagOptions are the VFS flags of a mount. They are reported back by mountinfo
+// and compared during reconciliation, filesystem specific options are not.
+var MountFlagOptions = []string{"ro", "nosuid", "nodev", "noexec", "sync", "dirsync", "noatime", "nodiratime", "relatime", "strictatime"}
+
+// mountClearFlagOptions are the defaults the kernel does not list, they clear a flag.
+var mountClearFlagOptions = []string{"rw", "suid", "dev", "exec", "async", "atime", "diratime"}
+
+// mountSymmetricFlagOptions must match both ways, the atime flags are left to the kernel
+// defaults unless the entry sets one.
+var mountSymmetricFlagOptions = []string{"ro", "nosuid", "nodev", "noexec", "sync", "dirsync"}
+
+type DesiredMount struct {
+	Entry *fstab.FstabLine
+	State string
+}
+
+func NewDesiredMounts(configs []*config.Config) ([]*DesiredMount, error) {
+	desired := make([]*DesiredMount, 0, len(configs))
+	for _, c := range configs {
+		ent, err := fstab.RenderFstabLine(c)
+		if err != nil {
+			return nil, err
+		}
+		desired = append(desired, &DesiredMount{Entry: ent, State: c.GetState()})
+	}
+	return desired, nil
+}
+
+type MountAction struct {
+	Action     string
+	MountPoint string
+	Entry      *fstab.FstabLine
+	Reason     string
+}
+
+func (a MountAction) String() string {
+	return fmt.Sprintf("%s %s: %s", a.Action, a.MountPoint, a.Reason)
+}
+
+// sameMountSource compares the device of an entry with the source mountinfo reports. Device
+// tags and symlinks are resolved in fsys, a tag that cannot be resolved is assumed to match.
+func sameMountSource(fsys vfs.FS, device, source string) bool {
+	if device == source {
+		return true
+	}
+	resolved, err := fsys.EvalSymlinks(config.DeviceTagToPath(device))
+	if err != nil {
+		return config.IsDeviceTag(device)
+	}
+	actual, err := fsys.EvalSymlinks(source)
+	if err != nil {
+		actual = source
+	}
+	return resolved == actual
+}
+
+// sameMountType treats nfs and nfs4 alike, the kernel reports the negotiated version.
+func sameMountType(fsType, mounted string) bool {
+	if fsType == mounted {
+		return true
+	}
+	return (fsType == "nfs" || fsType == "nfs4") && (mounted == "nfs" || mounted == "nfs4")
+}
+
+// mountFlagsDiffer compares the VFS flags of ent with the mounted ones.
+func mountFlagsDiffer(ent *fstab.FstabLine, mounted *MountedFileSystem) bool {
+	flags, _ := SplitMountOptions(ent.Options)
+	for _, flag := range mountSymmetricFlagOptions {
+		if containsOption(flags, flag) != mounted.HasOption(flag) {
+			return true
+		}
+	}
+	for _, flag := range flags {
+		if containsOption(MountFlagOptions, flag) && !mounted.HasOption(flag) {
+			return true
+		}
+	}
+	return false
+}
+
+// PlanMountActions returns the fewest actions bringing the mounts to the desired states:
+// unmounts first, nested mounts before their parent, then mounts and remounts parents first.
+// Swap and present entries are left alone. The devices are resolved in fsys.
+func PlanMountActions(fsys vfs.FS, desired []*DesiredMount, mounted []*MountedFileSystem) []MountAction {
+	// the last mount of a mount point hides the previous ones
+	top := make(map[string]*MountedFileSystem)
+	for _, m := range mounted {
+		top[m.MountPoint] = m
+	}
+
+	unmounts := make([]MountAction, 0)
+	mounts := make([]MountAction, 0)
+	for _, d := range desired {
+		ent := d.Entry
+		if ent.FileSystemType == "swap" || d.State == config.StatePresent {
+			continue
+		}
+		m, isMounted := top[ent.MountPoint]
+		switch d.State {
+		case config.StateUnmounted, config.StateAbsent:
+			if isMounted {
+				unmounts = append(unmounts, MountAction{MountActionUnmount, ent.MountPoint, ent, "state " + d.State})
+			}
+		case config.StateMounted:
+			switch {
+			case !isMounted:
+				mounts = append(mounts, MountAction{MountActionMount, ent.MountPoint, ent, "not mounted"})
+			case !sameMountSource(fsys, ent.Device, m.Source) || !sameMountType(ent.FileSystemType, m.Type):
+				reason := fmt.Sprintf("%s (%s) mounted instead of %s (%s)", m.Source, m.Type, ent.Device, ent.FileSystemType)
+				unmounts = append(unmounts, MountAction{MountActionUnmount, ent.MountPoint, ent, reason})
+				mounts = append(mounts, MountAction{MountActionMount, ent.MountPoint, ent, reason})
+			case mountFlagsDiffer(ent, m):
+				mounts = append(mounts, MountAction{MountActionRemount, ent.MountPoint, ent, "options changed"})
+			}
+		}
+	}
+
+	sort.SliceStable(unmounts, func(i, j int) bool {
+		return unmounts[i].MountPoint > unmounts[j].MountPoint
+	})
+	sort.SliceStable(mounts, func(i, j int) bool {
+		return mounts[i].MountPoint < mounts[j].MountPoint
+	})
+	return append(unmounts, mounts...)
+}
+
+// ApplyMountActions runs the actions in order and stops at the first failure, a *MountError,
+// returning the actions that were applied.
+func ApplyMountActions(m Mounter, actions []MountAction) ([]MountAction, error) {
+	for i, a := range actions {
+		var err error
+		switch a.Action {
+		case MountActionMount:
+			err = m.Mount(a.Entry)
+		case MountActionUnmount:
+			err = m.Unmount(a.MountPoint)
+		case MountActionRemount:
+			err = m.Remount(a.Entry)
+		default:
+			err = fmt.Errorf("unknown action %s", a.Action)
+		}
+		if err != nil {
+			return actions[:i], &MountError{Action: a.Action, MountPoint: a.MountPoint, Err: err}
+		}
+	}
+	return actions, nil
+}
+
+// Reconcile compares the desired states with the mounts listed in the mountinfo of fsys and
+// applies the planned actions, returning those that were applied.
+func Reconcile(fsys vfs.FS, m Mounter, desired []*DesiredMount, mountInfoPath string) ([]MountAction, error) {
+	mounted, err := ReadMountInfo(fsys, mountInfoPath)
+	if err != nil {
+		return nil, err
+	}
+	return ApplyMountActions(m, PlanMountActions(fsys, desired, mounted))
+}
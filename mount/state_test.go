@@ -0,0 +1,145 @@
+package mount
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+
+	"tienbm90/yml2fstab/config"
+	"tienbm90/yml2fstab/fstab"
+	"tienbm90/yml2fstab/validate"
+	"tienbm90/yml2fstab/vfs"
+)
+
+func TestValidateStateConfig(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		for _, state := range config.States {
+			cnf, err := config.NewConfigFromMapData("/dev/sdb1", map[string]interface{}{"mount": "/data", "type": "ext4", "state": state})
+			assert.NoError(t, err)
+			assert.NoError(t, validate.ValidateConfig(cnf))
+		}
+		swap, err := config.NewSwapConfigFromMapData("/dev/sda3", map[string]interface{}{"state": config.StateAbsent})
+		assert.NoError(t, err)
+		assert.NoError(t, validate.ValidateConfig(swap))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		cnf, err := config.NewConfigFromMapData("/dev/sdb1", map[string]interface{}{"mount": "/data", "type": "ext4", "state": "started"})
+		assert.NoError(t, err)
+		assert.Error(t, validate.ValidateConfig(cnf))
+
+		swap, err := config.NewSwapConfigFromMapData("/dev/sda3", map[string]interface{}{"state": config.StateMounted})
+		assert.NoError(t, err)
+		assert.Error(t, validate.ValidateConfig(swap))
+
+		_, err = config.NewConfigFromMapData("/dev/sdb1", map[string]interface{}{"mount": "/data", "type": "ext4", "state": true})
+		assert.Error(t, err)
+	})
+
+	t.Run("split absent", func(t *testing.T) {
+		configs := []*config.Config{
+			config.NewConfigWithOptions(config.WithConfigMount("/a")),
+			config.NewConfigWithOptions(config.WithConfigMount("/b"), config.WithConfigState(config.StateAbsent)),
+			config.NewConfigWithOptions(config.WithConfigMount("/c"), config.WithConfigState(config.StateMounted)),
+		}
+		present, absent := config.SplitAbsentConfigs(configs)
+		assert.Equal(t, []*config.Config{configs[0], configs[2]}, present)
+		assert.Equal(t, []*config.Config{configs[1]}, absent)
+	})
+}
+
+func TestPlanMountActions(t *testing.T) {
+	root := fstab.NewFstabEntry("/dev/sda2", "/", "ext4", "defaults", 0, 1)
+	data := fstab.NewFstabEntry("/dev/sdb1", "/data", "ext4", "noexec,nosuid", 0, 2)
+	cache := fstab.NewFstabEntry("/dev/sdb2", "/data/cache", "xfs", "defaults", 0, 0)
+	home := fstab.NewFstabEntry("192.168.4.5:/var/nfs/home", "/home", "nfs", "_netdev,nofail", 0, 0)
+	old := fstab.NewFstabEntry("192.168.4.5:/var/nfs/old", "/srv/old", "nfs", "defaults", 0, 0)
+
+	t.Run("success", func(t *testing.T) {
+		mounter := NewMemoryMounter(
+			root,
+			fstab.NewFstabEntry("/dev/sdb1", "/data", "ext4", "nosuid", 0, 2),
+			fstab.NewFstabEntry("/dev/sdc1", "/data/cache", "xfs", "defaults", 0, 0),
+			old,
+		)
+		desired := []*DesiredMount{
+			{Entry: root, State: config.StatePresent},
+			{Entry: data, State: config.StateMounted},
+			{Entry: cache, State: config.StateMounted},
+			{Entry: home, State: config.StateMounted},
+			{Entry: old, State: config.StateAbsent},
+			{Entry: fstab.NewFstabEntry("/dev/sda3", "swap", "swap", "defaults", 0, 0), State: config.StateAbsent},
+		}
+
+		actions := PlanMountActions(vfs.NewMemoryFS(), desired, mounter.MountedFileSystems())
+		plan := make([]string, 0)
+		for _, a := range actions {
+			plan = append(plan, a.Action+" "+a.MountPoint)
+		}
+		assert.Equal(t, []string{"unmount /srv/old", "unmount /data/cache", "remount /data", "mount /data/cache", "mount /home"}, plan)
+		assert.Equal(t, "remount /data: options changed", actions[2].String())
+
+		applied, err := ApplyMountActions(mounter, actions)
+		assert.NoError(t, err)
+		assert.Equal(t, actions, applied)
+		assert.Equal(t, plan, mounter.Calls)
+
+		// a second run has nothing to do
+		assert.Empty(t, PlanMountActions(vfs.NewMemoryFS(), desired, mounter.MountedFileSystems()))
+	})
+
+	t.Run("unmounted", func(t *testing.T) {
+		mounter := NewMemoryMounter(data)
+		desired := []*DesiredMount{
+			{Entry: data, State: config.StateUnmounted},
+			{Entry: cache, State: config.StateUnmounted},
+		}
+		applied, err := ApplyMountActions(mounter, PlanMountActions(vfs.NewMemoryFS(), desired, mounter.MountedFileSystems()))
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(applied))
+		assert.Empty(t, mounter.MountedFileSystems())
+	})
+
+	t.Run("nfs4 reported for nfs", func(t *testing.T) {
+		mounted := []*MountedFileSystem{{Source: home.Device, MountPoint: "/home", Type: "nfs4", MountOptions: []string{"rw", "relatime"}, SuperOptions: []string{"vers=4.2"}}}
+		assert.Empty(t, PlanMountActions(vfs.NewMemoryFS(), []*DesiredMount{{Entry: home, State: config.StateMounted}}, mounted))
+	})
+
+	t.Run("device tags resolved in fsys", func(t *testing.T) {
+		fsys := vfs.NewMemoryFS()
+		assert.NoError(t, fsys.MkdirAll("/dev/disk/by-uuid", 0755))
+		assert.NoError(t, vfs.WriteFile(fsys, "/dev/sdb1", nil, 0600))
+		assert.NoError(t, fsys.Symlink("../../sdb1", "/dev/disk/by-uuid/3e6be9de"))
+		srv := fstab.NewFstabEntry("UUID=3e6be9de", "/srv", "ext4", "defaults", 0, 2)
+		desired := []*DesiredMount{{Entry: srv, State: config.StateMounted}}
+
+		mounted := []*MountedFileSystem{{Source: "/dev/sdb1", MountPoint: "/srv", Type: "ext4", MountOptions: []string{"rw"}}}
+		assert.Empty(t, PlanMountActions(fsys, desired, mounted))
+		mounted[0].Source = "/dev/sdc1"
+		assert.Equal(t, 2, len(PlanMountActions(fsys, desired, mounted)))
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		mounter := NewMemoryMounter()
+		actions := []MountAction{
+			{Action: MountActionMount, MountPoint: "/data", Entry: data},
+			{Action: MountActionUnmount, MountPoint: "/home", Entry: home},
+			{Action: MountActionMount, MountPoint: "/data/cache", Entry: cache},
+		}
+		applied, err := ApplyMountActions(mounter, actions)
+		assert.True(t, errors.Is(err, ErrMount))
+		var merr *MountError
+		assert.True(t, errors.As(err, &merr))
+		assert.Equal(t, "/home", merr.MountPoint)
+		assert.Equal(t, actions[:1], applied)
+		assert.Equal(t, []string{"mount /data", "unmount /home"}, mounter.Calls)
+	})
+}
+
+func TestSplitMountOptions(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		flags, data := SplitMountOptions("defaults,ro,noexec,_netdev,nofail,x-systemd.automount,comment=x,vers=4,rsize=8192,exec")
+		assert.Equal(t, []string{"ro", "noexec", "exec"}, flags)
+		assert.Equal(t, "vers=4,rsize=8192", data)
+	})
+}
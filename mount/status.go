@@ -0,0 +1,134 @@
+package mount
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"tienbm90/yml2fstab/config"
+	"tienbm90/yml2fstab/fstab"
+	"tienbm90/yml2fstab/vfs"
+)
+
+// MountStatus compares a rendered entry with its mount on the running system.
+type MountStatus struct {
+	Entry *fstab.FstabLine
+	// Mounted is nil when nothing is mounted on the mount point
+	Mounted *MountedFileSystem
+	// AddedOptions are reported by the kernel without being set by the entry
+	AddedOptions []string
+	// IgnoredOptions are set by the entry but not reported by the kernel
+	IgnoredOptions []string
+	sourceDiffers  bool
+}
+
+func (s *MountStatus) IsMounted() bool {
+	return s.Mounted != nil
+}
+
+func (s *MountStatus) SourceDiffers() bool {
+	return s.sourceDiffers
+}
+
+func (s *MountStatus) TypeDiffers() bool {
+	return s.IsMounted() && !sameMountType(s.Entry.FileSystemType, s.Mounted.Type)
+}
+
+// kernelOptions returns the options the kernel sees, without those only read by mount(8)
+// and the defaults it never reports.
+func kernelOptions(options string) []string {
+	flags, data := SplitMountOptions(options)
+	opts := make([]string, 0)
+	for _, opt := range append(flags, fstab.SplitOptions(data)...) {
+		if !containsOption(mountClearFlagOptions, opt) || opt == "rw" {
+			opts = append(opts, opt)
+		}
+	}
+	return opts
+}
+
+// NewMountStatus compares ent with the mount found on its mount point, if any. The device of
+// ent is resolved in fsys.
+func NewMountStatus(fsys vfs.FS, ent *fstab.FstabLine, mounted []*MountedFileSystem) *MountStatus {
+	status := &MountStatus{Entry: ent}
+	m, ok := FindMountedFileSystem(mounted, ent.MountPoint)
+	if !ok {
+		return status
+	}
+	status.Mounted = m
+	status.sourceDiffers = !sameMountSource(fsys, ent.Device, m.Source)
+
+	wanted := kernelOptions(ent.Options)
+	if !containsOption(wanted, "ro") && !containsOption(wanted, "rw") {
+		wanted = append(wanted, "rw")
+	}
+	for _, opt := range wanted {
+		if !m.HasOption(opt) {
+			status.IgnoredOptions = append(status.IgnoredOptions, opt)
+		}
+	}
+	for _, opt := range m.Options() {
+		if !m.HasOption(opt) {
+			// ro or rw of the super block
+			continue
+		}
+		if !containsOption(wanted, opt) && !containsOption(status.AddedOptions, opt) {
+			status.AddedOptions = append(status.AddedOptions, opt)
+		}
+	}
+	return status
+}
+
+// MountStatuses returns the status of every entry, swap is not mounted and is skipped.
+func MountStatuses(fsys vfs.FS, entries []*fstab.FstabLine, mounted []*MountedFileSystem) []*MountStatus {
+	statuses := make([]*MountStatus, 0, len(entries))
+	for _, ent := range entries {
+		if ent.FileSystemType == "swap" {
+			continue
+		}
+		statuses = append(statuses, NewMountStatus(fsys, ent, mounted))
+	}
+	return statuses
+}
+
+func WriteMountStatuses(w io.Writer, statuses []*MountStatus) error {
+	mountedCount := 0
+	for _, s := range statuses {
+		if !s.IsMounted() {
+			_, err := fmt.Fprintf(w, "%s: not mounted\n", s.Entry.MountPoint)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		mountedCount++
+		m := s.Mounted
+		lines := []string{
+			fmt.Sprintf("%s: mounted", s.Entry.MountPoint),
+			fmt.Sprintf("  source: %s", m.Source),
+			fmt.Sprintf("  type: %s", m.Type),
+			fmt.Sprintf("  options: %s", config.BuildStringFromSlice(m.MountOptions)),
+		}
+		if len(m.SuperOptions) > 0 {
+			lines = append(lines, fmt.Sprintf("  super options: %s", config.BuildStringFromSlice(m.SuperOptions)))
+		}
+		if s.SourceDiffers() {
+			lines = append(lines, fmt.Sprintf("  !! source differs, fstab has %s", s.Entry.Device))
+		}
+		if s.TypeDiffers() {
+			lines = append(lines, fmt.Sprintf("  !! type differs, fstab has %s", s.Entry.FileSystemType))
+		}
+		if len(s.AddedOptions) > 0 {
+			lines = append(lines, fmt.Sprintf("  added by the kernel: %s", config.BuildStringFromSlice(s.AddedOptions)))
+		}
+		if len(s.IgnoredOptions) > 0 {
+			lines = append(lines, fmt.Sprintf("  not applied: %s", config.BuildStringFromSlice(s.IgnoredOptions)))
+		}
+		_, err := fmt.Fprintln(w, strings.Join(lines, "\n"))
+		if err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "%d entries, %d mounted\n", len(statuses), mountedCount)
+	return err
+}
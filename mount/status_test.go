@@ -0,0 +1,77 @@
+package mount
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+
+	"tienbm90/yml2fstab/fstab"
+	"tienbm90/yml2fstab/vfs"
+)
+
+func TestMountStatuses(t *testing.T) {
+	entries := []*fstab.FstabLine{
+		fstab.NewFstabEntry("/dev/sda2", "/", "ext4", "defaults", 0, 1),
+		fstab.NewFstabEntry("192.168.4.5:/var/nfs/home", "/home", "nfs", "noexec,nosuid,vers=4,_netdev", 0, 0),
+		fstab.NewFstabEntry("/dev/sdc1", "/scratch", "xfs", "defaults", 0, 0),
+		fstab.NewFstabEntry("/dev/sda3", "swap", "swap", "defaults", 0, 0),
+	}
+	mounted, err := ParseMountInfo(`22 1 8:2 / / rw,relatime shared:1 - ext4 /dev/sda2 rw
+41 22 0:45 / /home rw,nosuid,noexec,relatime - nfs4 192.168.4.5:/var/nfs/home rw,vers=4.2
+`)
+	assert.NoError(t, err)
+
+	t.Run("success", func(t *testing.T) {
+		statuses := MountStatuses(vfs.NewMemoryFS(), entries, mounted)
+		assert.Equal(t, 3, len(statuses))
+
+		root := statuses[0]
+		assert.True(t, root.IsMounted())
+		assert.False(t, root.SourceDiffers())
+		assert.Equal(t, []string{"relatime"}, root.AddedOptions)
+		assert.Empty(t, root.IgnoredOptions)
+
+		home := statuses[1]
+		assert.False(t, home.TypeDiffers())
+		assert.Equal(t, []string{"relatime", "vers=4.2"}, home.AddedOptions)
+		assert.Equal(t, []string{"vers=4"}, home.IgnoredOptions)
+
+		assert.False(t, statuses[2].IsMounted())
+	})
+
+	t.Run("write", func(t *testing.T) {
+		var b bytes.Buffer
+		assert.NoError(t, WriteMountStatuses(&b, MountStatuses(vfs.NewMemoryFS(), entries, mounted)))
+		assert.Equal(t, `/: mounted
+  source: /dev/sda2
+  type: ext4
+  options: rw,relatime
+  super options: rw
+  added by the kernel: relatime
+/home: mounted
+  source: 192.168.4.5:/var/nfs/home
+  type: nfs4
+  options: rw,nosuid,noexec,relatime
+  super options: rw,vers=4.2
+  added by the kernel: relatime,vers=4.2
+  not applied: vers=4
+/scratch: not mounted
+3 entries, 2 mounted
+`, b.String())
+	})
+
+	t.Run("differs", func(t *testing.T) {
+		other, err := ParseMountInfo("50 22 8:33 / /scratch ro - ext4 /dev/sdd1 rw\n")
+		assert.NoError(t, err)
+		s := NewMountStatus(vfs.NewMemoryFS(), entries[2], other)
+		assert.True(t, s.SourceDiffers())
+		assert.True(t, s.TypeDiffers())
+		assert.Equal(t, []string{"rw"}, s.IgnoredOptions)
+		assert.Equal(t, []string{"ro"}, s.AddedOptions)
+
+		var b bytes.Buffer
+		assert.NoError(t, WriteMountStatuses(&b, []*MountStatus{s}))
+		assert.Contains(t, b.String(), "!! source differs, fstab has /dev/sdc1")
+		assert.Contains(t, b.String(), "!! type differs, fstab has xfs")
+	})
+}
@@ -0,0 +1,4 @@
+// Package plugin registers external yml2fstab-type-<name> executables as filesystem types.
+//
+// Plugin errors name the executable, a plugin that times out or exits non-zero fails the render.
+package plugin
@@ -0,0 +1,247 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"tienbm90/yml2fstab/config"
+	"tienbm90/yml2fstab/fstab"
+	"tienbm90/yml2fstab/vfs"
+)
+
+const (
+	// PluginPrefix is the executable name prefix of external filesystem type plugins.
+	PluginPrefix = "yml2fstab-type-"
+	// DefaultPluginTimeout bounds a single plugin run.
+	DefaultPluginTimeout = 5 * time.Second
+)
+
+// PluginMessage is a validation message reported by a plugin. Level is "error" or "warning".
+type PluginMessage struct {
+	Level   string `json:"level"`
+	Message string `json:"message"`
+}
+
+// PluginResponse is read as JSON from the plugin stdout. The request is the entry Config as JSON on stdin.
+type PluginResponse struct {
+	Line     *fstab.FstabLine `json:"line"`
+	Messages []PluginMessage  `json:"messages"`
+}
+
+func (r *PluginResponse) Errors() []string {
+	errs := make([]string, 0)
+	for _, m := range r.Messages {
+		if m.Level == "error" {
+			errs = append(errs, m.Message)
+		}
+	}
+	return errs
+}
+
+// pluginResult is the outcome of one plugin run, kept so the plugin answers once per config.
+type pluginResult struct {
+	resp *PluginResponse
+	err  error
+}
+
+// PluginFileSystemType is a filesystem type rendered and validated by an external executable.
+type PluginFileSystemType struct {
+	name    string
+	path    string
+	timeout time.Duration
+	// logger receives the warnings and notices of the plugin
+	logger *log.Logger
+
+	mu      sync.Mutex
+	results map[string]*pluginResult
+}
+
+// NewPluginFileSystemType returns the type name rendered by the executable at path. The
+// messages of the plugin that are not errors are logged to logger, a nil logger drops them.
+func NewPluginFileSystemType(name string, path string, timeout time.Duration, logger *log.Logger) *PluginFileSystemType {
+	if logger == nil {
+		logger = log.New(ioutil.Discard, "", 0)
+	}
+	return &PluginFileSystemType{name: name, path: path, timeout: timeout, logger: logger, results: make(map[string]*pluginResult)}
+}
+
+func (p *PluginFileSystemType) Name() string {
+	return p.name
+}
+
+func (p *PluginFileSystemType) Path() string {
+	return p.path
+}
+
+func (p *PluginFileSystemType) RenderSource(c *config.Config) (string, error) {
+	line, err := p.RenderFstabLine(c)
+	if err != nil {
+		return "", err
+	}
+	return line.Device, nil
+}
+
+func (p *PluginFileSystemType) OptionCatalog() []string {
+	return nil
+}
+
+func (p *PluginFileSystemType) DefaultPass(c *config.Config) int {
+	return 0
+}
+
+func (p *PluginFileSystemType) IsNetwork() bool {
+	return false
+}
+
+func (p *PluginFileSystemType) Validate(c *config.Config) error {
+	_, err := p.run(c)
+	return err
+}
+
+func (p *PluginFileSystemType) RenderFstabLine(c *config.Config) (*fstab.FstabLine, error) {
+	resp, err := p.run(c)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Line, nil
+}
+
+// run returns the plugin response for c, executing the plugin only the first time it sees the
+// config. Validate and the renderers of one entry all get the same answer.
+func (p *PluginFileSystemType) run(c *config.Config) (*PluginResponse, error) {
+	request, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if r, ok := p.results[string(request)]; ok {
+		return r.resp, r.err
+	}
+	resp, err := p.execute(c, request)
+	p.results[string(request)] = &pluginResult{resp: resp, err: err}
+	return resp, err
+}
+
+// execute runs the plugin once. Error messages and a missing or incomplete line fail the
+// run, warnings are logged.
+func (p *PluginFileSystemType) execute(c *config.Config, request []byte) (*PluginResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.path)
+	cmd.Stdin = bytes.NewReader(request)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("plugin %s timed out after %s", p.path, p.timeout)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s failed: %s: %s", p.path, err, strings.TrimSpace(stderr.String()))
+	}
+
+	resp := &PluginResponse{}
+	err = json.Unmarshal(stdout.Bytes(), resp)
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s returned invalid response: %s", p.path, err)
+	}
+	for _, m := range resp.Messages {
+		if m.Level != "error" {
+			p.logger.Printf("plugin %s: %s: %s", p.name, c.Source, m.Message)
+		}
+	}
+	if errs := resp.Errors(); len(errs) > 0 {
+		return nil, fmt.Errorf("%s: %s", c.Source, strings.Join(errs, "; "))
+	}
+	if resp.Line == nil {
+		return nil, fmt.Errorf("plugin %s returned no line for %s", p.path, c.Source)
+	}
+	if missing := missingLineFields(resp.Line); len(missing) > 0 {
+		return nil, fmt.Errorf("plugin %s returned a line without %s for %s", p.path, strings.Join(missing, ", "), c.Source)
+	}
+	return resp, nil
+}
+
+// missingLineFields lists the text columns of line that are empty and would shift the others.
+func missingLineFields(line *fstab.FstabLine) []string {
+	missing := make([]string, 0)
+	for _, f := range []struct{ name, value string }{
+		{"device", line.Device},
+		{"mount point", line.MountPoint},
+		{"type", line.FileSystemType},
+		{"options", line.Options},
+	} {
+		if strings.TrimSpace(f.value) == "" {
+			missing = append(missing, f.name)
+		}
+	}
+	return missing
+}
+
+// DiscoverPlugins finds the yml2fstab-type-<name> executables in a PATH style list of
+// directories of fsys. Like PATH lookup, the first directory providing a name wins. The
+// plugins log to logger.
+func DiscoverPlugins(fsys vfs.FS, logger *log.Logger, pluginPath string, timeout time.Duration) ([]*PluginFileSystemType, error) {
+	plugins := make([]*PluginFileSystemType, 0)
+	seen := make(map[string]bool)
+	for _, dir := range filepath.SplitList(pluginPath) {
+		if dir == "" {
+			continue
+		}
+		files, err := fsys.ReadDir(dir)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			name := strings.TrimPrefix(f.Name(), PluginPrefix)
+			if name == f.Name() || name == "" || seen[name] {
+				continue
+			}
+			path := filepath.Join(dir, f.Name())
+			// follow symlinks to check the target is an executable file
+			info, err := fsys.Stat(path)
+			if err != nil || !info.Mode().IsRegular() || info.Mode()&0111 == 0 {
+				continue
+			}
+			seen[name] = true
+			plugins = append(plugins, NewPluginFileSystemType(name, path, timeout, logger))
+		}
+	}
+	return plugins, nil
+}
+
+// RegisterPlugins discovers the plugins on pluginPath of fsys and registers them in the default registry.
+// A plugin never replaces a type that is already registered, such as the built-in nfs, it is
+// skipped with a warning on logger.
+func RegisterPlugins(fsys vfs.FS, logger *log.Logger, pluginPath string, timeout time.Duration) error {
+	plugins, err := DiscoverPlugins(fsys, logger, pluginPath, timeout)
+	if err != nil {
+		return err
+	}
+	for _, p := range plugins {
+		if _, ok := config.LookupFileSystemType(p.Name()); ok {
+			logger.Printf("plugin %s ignored: %s is already a registered filesystem type", p.Path(), p.Name())
+			continue
+		}
+		config.RegisterFileSystemType(p)
+	}
+	return nil
+}
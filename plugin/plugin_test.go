@@ -0,0 +1,198 @@
+package plugin
+
+import (
+	"bytes"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"log"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"tienbm90/yml2fstab/config"
+	"tienbm90/yml2fstab/fstab"
+	"tienbm90/yml2fstab/vfs"
+)
+
+func writePlugin(t *testing.T, dir string, name string, script string) string {
+	path := filepath.Join(dir, PluginPrefix+name)
+	assert.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0755))
+	return path
+}
+
+func TestDiscoverPlugins(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		first := t.TempDir()
+		second := t.TempDir()
+		writePlugin(t, first, "s3fs", "exit 0\n")
+		writePlugin(t, second, "s3fs", "exit 1\n")
+		writePlugin(t, second, "rclone", "exit 0\n")
+		// not executable
+		assert.NoError(t, os.WriteFile(filepath.Join(second, PluginPrefix+"gcsfuse"), []byte(""), 0644))
+		// not a plugin
+		assert.NoError(t, os.WriteFile(filepath.Join(second, "s3fs"), []byte(""), 0755))
+
+		pluginPath := first + string(os.PathListSeparator) + second + string(os.PathListSeparator) + filepath.Join(first, "missing")
+		plugins, err := DiscoverPlugins(vfs.NewOSFS(), nil, pluginPath, time.Second)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(plugins))
+
+		names := make(map[string]string)
+		for _, p := range plugins {
+			names[p.Name()] = p.Path()
+		}
+		assert.Equal(t, filepath.Join(first, PluginPrefix+"s3fs"), names["s3fs"])
+		assert.Contains(t, names, "rclone")
+	})
+
+	t.Run("memory", func(t *testing.T) {
+		fsys := vfs.NewMemoryFS()
+		assert.NoError(t, fsys.MkdirAll("/usr/libexec/yml2fstab", 0755))
+		assert.NoError(t, vfs.WriteFile(fsys, "/usr/libexec/yml2fstab/"+PluginPrefix+"s3fs", []byte(""), 0755))
+		assert.NoError(t, vfs.WriteFile(fsys, "/usr/libexec/yml2fstab/"+PluginPrefix+"gcsfuse", []byte(""), 0644))
+
+		plugins, err := DiscoverPlugins(fsys, nil, "/usr/libexec/yml2fstab"+string(os.PathListSeparator)+"/missing", time.Second)
+		assert.NoError(t, err)
+		if assert.Equal(t, 1, len(plugins)) {
+			assert.Equal(t, "s3fs", plugins[0].Name())
+		}
+
+		fsys.Fail(vfs.OpReadDir, "/usr/libexec/yml2fstab", syscall.EACCES)
+		_, err = DiscoverPlugins(fsys, nil, "/usr/libexec/yml2fstab", time.Second)
+		assert.True(t, errors.Is(err, syscall.EACCES))
+	})
+}
+
+func TestRegisterPlugins(t *testing.T) {
+	t.Run("built-in types are kept", func(t *testing.T) {
+		dir := t.TempDir()
+		writePlugin(t, dir, "nfs", "exit 0\n")
+		writePlugin(t, dir, "rclone", "exit 0\n")
+		defer config.DefaultFileSystemRegistry.Unregister("rclone")
+
+		var warnings bytes.Buffer
+		assert.NoError(t, RegisterPlugins(vfs.NewOSFS(), log.New(&warnings, "", 0), dir, time.Second))
+		assert.Equal(t, "plugin "+filepath.Join(dir, PluginPrefix+"nfs")+" ignored: nfs is already a registered filesystem type\n", warnings.String())
+		nfs, ok := config.LookupFileSystemType("nfs")
+		assert.True(t, ok)
+		assert.IsType(t, &config.BasicFileSystemType{}, nfs)
+		rclone, ok := config.LookupFileSystemType("rclone")
+		assert.True(t, ok)
+		assert.IsType(t, &PluginFileSystemType{}, rclone)
+	})
+}
+
+func TestPluginFileSystemType(t *testing.T) {
+	cnf := config.NewConfigWithOptions(
+		config.WithConfigSource("mybucket"),
+		config.WithConfigMount("/mnt/s3"),
+		config.WithConfigFSType("fuse.s3fs"),
+	)
+
+	t.Run("render", func(t *testing.T) {
+		dir := t.TempDir()
+		// echo the request back so the test can check what was sent
+		path := writePlugin(t, dir, "s3fs", `cat > "$0.request"
+echo '{"line":{"device":"mybucket","mount_point":"/mnt/s3","type":"fuse.s3fs","options":"_netdev,allow_other","dump":0,"pass":0},"messages":[{"level":"warning","message":"no credentials file"}]}'
+`)
+		var messages bytes.Buffer
+		p := NewPluginFileSystemType("s3fs", path, time.Second, log.New(&messages, "", 0))
+		line, err := p.RenderFstabLine(cnf)
+		assert.NoError(t, err)
+		assert.Equal(t, "plugin s3fs: mybucket: no credentials file\n", messages.String())
+		assert.Equal(t, "mybucket /mnt/s3 fuse.s3fs _netdev,allow_other 0 0", line.GenerateFstabEntryString())
+		assert.NoError(t, p.Validate(cnf))
+		source, err := p.RenderSource(cnf)
+		assert.NoError(t, err)
+		assert.Equal(t, "mybucket", source)
+
+		request, err := os.ReadFile(path + ".request")
+		assert.NoError(t, err)
+		assert.Contains(t, string(request), `"source":"mybucket"`)
+		assert.Contains(t, string(request), `"mount":"/mnt/s3"`)
+	})
+
+	t.Run("one run per config", func(t *testing.T) {
+		path := writePlugin(t, t.TempDir(), "s3fs", `cat > /dev/null
+echo run >> "$0.runs"
+echo '{"line":{"device":"mybucket","mount_point":"/mnt/s3","type":"fuse.s3fs","options":"defaults","dump":0,"pass":0}}'
+`)
+		p := NewPluginFileSystemType("s3fs", path, time.Second, nil)
+		assert.NoError(t, p.Validate(cnf))
+		_, err := p.RenderFstabLine(cnf)
+		assert.NoError(t, err)
+		_, err = p.RenderSource(cnf)
+		assert.NoError(t, err)
+		runs, _ := os.ReadFile(path + ".runs")
+		assert.Equal(t, "run\n", string(runs))
+
+		other := *cnf
+		other.Source = "otherbucket"
+		assert.NoError(t, p.Validate(&other))
+		runs, _ = os.ReadFile(path + ".runs")
+		assert.Equal(t, "run\nrun\n", string(runs))
+	})
+
+	t.Run("render source error", func(t *testing.T) {
+		path := writePlugin(t, t.TempDir(), "s3fs", `cat > /dev/null
+echo '{"messages":[{"level":"error","message":"bucket not allowed"}]}'
+`)
+		source, err := NewPluginFileSystemType("s3fs", path, time.Second, nil).RenderSource(cnf)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "bucket not allowed")
+		assert.Empty(t, source)
+	})
+
+	t.Run("incomplete line", func(t *testing.T) {
+		path := writePlugin(t, t.TempDir(), "s3fs", `cat > /dev/null
+echo '{"line":{"device":"mybucket","type":"fuse.s3fs","options":" ","dump":0,"pass":0}}'
+`)
+		_, err := NewPluginFileSystemType("s3fs", path, time.Second, nil).RenderFstabLine(cnf)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "without mount point, options")
+	})
+
+	t.Run("validation errors", func(t *testing.T) {
+		path := writePlugin(t, t.TempDir(), "s3fs", `cat > /dev/null
+echo '{"messages":[{"level":"error","message":"bucket not allowed"}]}'
+`)
+		p := NewPluginFileSystemType("s3fs", path, time.Second, nil)
+		err := p.Validate(cnf)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "bucket not allowed")
+	})
+
+	t.Run("invalid response", func(t *testing.T) {
+		path := writePlugin(t, t.TempDir(), "s3fs", "cat > /dev/null\necho not json\n")
+		_, err := NewPluginFileSystemType("s3fs", path, time.Second, nil).RenderFstabLine(cnf)
+		assert.Error(t, err)
+	})
+
+	t.Run("failure exit code", func(t *testing.T) {
+		path := writePlugin(t, t.TempDir(), "s3fs", "echo broken >&2\nexit 3\n")
+		_, err := NewPluginFileSystemType("s3fs", path, time.Second, nil).RenderFstabLine(cnf)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "broken")
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		path := writePlugin(t, t.TempDir(), "s3fs", "exec sleep 5\n")
+		_, err := NewPluginFileSystemType("s3fs", path, 100*time.Millisecond, nil).RenderFstabLine(cnf)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "timed out")
+	})
+
+	t.Run("rendered through the registry", func(t *testing.T) {
+		path := writePlugin(t, t.TempDir(), "s3fs", `cat > /dev/null
+echo '{"line":{"device":"mybucket","mount_point":"/mnt/s3","type":"fuse.s3fs","options":"defaults","dump":0,"pass":0}}'
+`)
+		config.RegisterFileSystemType(NewPluginFileSystemType("fuse.s3fs", path, time.Second, nil))
+		defer config.DefaultFileSystemRegistry.Unregister("fuse.s3fs")
+
+		line, err := fstab.RenderFstabLine(cnf)
+		assert.NoError(t, err)
+		assert.Equal(t, "mybucket /mnt/s3 fuse.s3fs defaults 0 0", line.GenerateFstabEntryString())
+	})
+}
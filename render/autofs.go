@@ -0,0 +1,118 @@
+package render
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"tienbm90/yml2fstab/config"
+	"tienbm90/yml2fstab/fstab"
+	"tienbm90/yml2fstab/vfs"
+)
+
+const (
+	// AutofsMasterFile is the name of the master map listing the other maps.
+	AutofsMasterFile = "auto.master"
+	// autofsDirectKey is the auto.master mount point of direct maps.
+	autofsDirectKey = "/-"
+)
+
+type AutofsMap struct {
+	// file name of the map
+	Name string
+	// auto.master key, /- for direct maps or the parent directory for indirect maps
+	MountPoint string
+	Entries    []string
+}
+
+func (m *AutofsMap) String() string {
+	var b strings.Builder
+	b.WriteString("# Automatically generated by yml2fstab\n")
+	for _, ent := range m.Entries {
+		b.WriteString(ent)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// GenerateAutofsEntryString renders the map line "key -fstype=type,options location".
+func GenerateAutofsEntryString(key string, c *config.Config) (string, error) {
+	options := []string{"-fstype=" + c.GetFileSystemType()}
+	if opts := c.GenerateOptionString(); opts != "defaults" {
+		options = append(options, opts)
+	}
+	// local devices and UNC paths need a leading colon
+	location, err := config.RenderMountDevice(c)
+	if err != nil {
+		return "", err
+	}
+	if strings.HasPrefix(location, "/") {
+		location = ":" + location
+	}
+	return fmt.Sprintf("%s %s %s", key, config.BuildStringFromSlice(options), location), nil
+}
+
+// NewAutofsMaps groups the autofs entries into maps. Direct entries go to auto.<type>,
+// indirect entries to one map per parent directory, for example auto.home for /home/alice.
+func NewAutofsMaps(configs []*config.Config) ([]*AutofsMap, error) {
+	maps := make(map[string]*AutofsMap)
+	for _, c := range configs {
+		name, mountPoint, key := "auto."+c.GetFileSystemType(), autofsDirectKey, c.GetMountPoint()
+		if c.GetAutofsMap() == config.AutofsMapIndirect {
+			mountPoint = path.Dir(c.GetMountPoint())
+			name = "auto." + SystemdEscapePath(mountPoint)
+			key = path.Base(c.GetMountPoint())
+		}
+
+		m, ok := maps[name]
+		if !ok {
+			m = &AutofsMap{Name: name, MountPoint: mountPoint}
+			maps[name] = m
+		}
+		if m.MountPoint != mountPoint {
+			return nil, fmt.Errorf("autofs map %s is used for both %s and %s", name, m.MountPoint, mountPoint)
+		}
+		entry, err := GenerateAutofsEntryString(key, c)
+		if err != nil {
+			return nil, err
+		}
+		m.Entries = append(m.Entries, entry)
+	}
+
+	result := make([]*AutofsMap, 0, len(maps))
+	for _, m := range maps {
+		result = append(result, m)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+// GenerateAutoMaster renders auto.master for maps stored in dir.
+func GenerateAutoMaster(maps []*AutofsMap, dir string) string {
+	var b strings.Builder
+	b.WriteString("# Automatically generated by yml2fstab\n")
+	for _, m := range maps {
+		fmt.Fprintf(&b, "%s %s\n", m.MountPoint, filepath.Join(dir, m.Name))
+	}
+	return b.String()
+}
+
+// WriteAutofsMaps writes the maps and auto.master into dir. auto.master names the maps in
+// mapDir, the path of dir on the system autofs runs on, which is not dir under a -root.
+func WriteAutofsMaps(fsys vfs.FS, maps []*AutofsMap, dir string, mapDir string) error {
+	err := fsys.MkdirAll(dir, 0755)
+	if err != nil {
+		return &fstab.WriteError{Path: dir, Err: err}
+	}
+	for _, m := range maps {
+		err := fstab.WriteFile(fsys, filepath.Join(dir, m.Name), []byte(m.String()))
+		if err != nil {
+			return err
+		}
+	}
+	return fstab.WriteFile(fsys, filepath.Join(dir, AutofsMasterFile), []byte(GenerateAutoMaster(maps, mapDir)))
+}
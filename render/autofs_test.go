@@ -0,0 +1,136 @@
+package render
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"tienbm90/yml2fstab/config"
+	"tienbm90/yml2fstab/validate"
+	"tienbm90/yml2fstab/vfs"
+)
+
+func TestNewConfigFromMapDataAutofs(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		data := make(map[string]interface{})
+		data["mount"] = "/home/alice"
+		data["export"] = "/var/nfs/home/alice"
+		data["type"] = "nfs"
+		data["automount"] = "autofs"
+		data["autofs-map"] = "indirect"
+
+		cnf, err := config.NewConfigFromMapData("192.168.4.5", data)
+		assert.NoError(t, err)
+		assert.True(t, cnf.IsAutofs())
+		assert.Equal(t, config.AutofsMapIndirect, cnf.GetAutofsMap())
+		assert.NoError(t, validate.ValidateConfig(cnf))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		invalid := []map[string]interface{}{
+			{"mount": "/home", "type": "nfs", "export": "/home", "automount": "amd"},
+			{"mount": "/home", "type": "nfs", "export": "/home", "automount": "autofs", "autofs-map": "hash"},
+			{"mount": "/home", "type": "nfs", "export": "/home", "automount": "autofs", "autofs-map": "indirect"},
+		}
+		for _, data := range invalid {
+			cnf, err := config.NewConfigFromMapData("192.168.4.5", data)
+			assert.NoError(t, err)
+			assert.Error(t, validate.ValidateConfig(cnf))
+		}
+
+		_, err := config.NewConfigFromMapData("192.168.4.5", map[string]interface{}{"mount": "/home", "type": "nfs", "automount": true})
+		assert.Error(t, err)
+	})
+}
+
+func TestNewAutofsMaps(t *testing.T) {
+	configs := []*config.Config{
+		config.NewConfigWithOptions(config.WithConfigSource("/dev/sda2"), config.WithConfigMount("/"), config.WithConfigFSType("ext4")),
+		config.NewConfigWithOptions(config.WithConfigSource("192.168.4.5"), config.WithConfigMount("/home/alice"), config.WithConfigExport("/var/nfs/home/alice"),
+			config.WithConfigFSType("nfs"), config.WithConfigAutomount(config.AutomountAutofs), config.WithConfigAutofsMap(config.AutofsMapIndirect)),
+		config.NewConfigWithOptions(config.WithConfigSource("fileserver"), config.WithConfigMount("/home/bob"), config.WithConfigExport("/bob"),
+			config.WithConfigFSType("cifs"), config.WithConfigOptions([]string{"credentials=/etc/bob.cred"}),
+			config.WithConfigAutomount(config.AutomountAutofs), config.WithConfigAutofsMap(config.AutofsMapIndirect)),
+		config.NewConfigWithOptions(config.WithConfigSource("192.168.4.5"), config.WithConfigMount("/srv/archive"), config.WithConfigExport("/archive"),
+			config.WithConfigFSType("nfs"), config.WithConfigOptions([]string{"ro", "soft"}), config.WithConfigAutomount(config.AutomountAutofs)),
+		config.NewConfigWithOptions(config.WithConfigSource("fileserver"), config.WithConfigMount("/srv/public"), config.WithConfigExport("/public"),
+			config.WithConfigFSType("cifs"), config.WithConfigAutomount(config.AutomountAutofs)),
+		config.NewConfigWithOptions(config.WithConfigSource("/dev/sr0"), config.WithConfigMount("/media/cdrom"),
+			config.WithConfigFSType("iso9660"), config.WithConfigOptions([]string{"ro"}), config.WithConfigAutomount(config.AutomountAutofs)),
+	}
+
+	t.Run("split", func(t *testing.T) {
+		fstab, autofs := config.SplitAutofsConfigs(configs)
+		assert.Equal(t, 1, len(fstab))
+		assert.Equal(t, 5, len(autofs))
+	})
+
+	t.Run("maps", func(t *testing.T) {
+		_, autofs := config.SplitAutofsConfigs(configs)
+		maps, err := NewAutofsMaps(autofs)
+		assert.NoError(t, err)
+		assert.Equal(t, 4, len(maps))
+
+		assert.Equal(t, "auto.cifs", maps[0].Name)
+		assert.Equal(t, "/-", maps[0].MountPoint)
+		assert.Equal(t, []string{"/srv/public -fstype=cifs ://fileserver/public"}, maps[0].Entries)
+
+		assert.Equal(t, "auto.home", maps[1].Name)
+		assert.Equal(t, "/home", maps[1].MountPoint)
+		assert.Equal(t, []string{
+			"alice -fstype=nfs 192.168.4.5:/var/nfs/home/alice",
+			"bob -fstype=cifs,credentials=/etc/bob.cred ://fileserver/bob",
+		}, maps[1].Entries)
+
+		assert.Equal(t, "auto.iso9660", maps[2].Name)
+		assert.Equal(t, []string{"/media/cdrom -fstype=iso9660,ro :/dev/sr0"}, maps[2].Entries)
+
+		assert.Equal(t, "auto.nfs", maps[3].Name)
+		assert.Equal(t, "# Automatically generated by yml2fstab\n/srv/archive -fstype=nfs,ro,soft 192.168.4.5:/archive\n", maps[3].String())
+
+		assert.Equal(t, `# Automatically generated by yml2fstab
+/- /etc/auto.cifs
+/home /etc/auto.home
+/- /etc/auto.iso9660
+/- /etc/auto.nfs
+`, GenerateAutoMaster(maps, "/etc"))
+	})
+
+	t.Run("map name clash", func(t *testing.T) {
+		clash := []*config.Config{
+			config.NewConfigWithOptions(config.WithConfigSource("192.168.4.5"), config.WithConfigMount("/srv/a"), config.WithConfigExport("/a"),
+				config.WithConfigFSType("nfs"), config.WithConfigAutomount(config.AutomountAutofs)),
+			config.NewConfigWithOptions(config.WithConfigSource("192.168.4.5"), config.WithConfigMount("/nfs/b"), config.WithConfigExport("/b"),
+				config.WithConfigFSType("nfs"), config.WithConfigAutomount(config.AutomountAutofs), config.WithConfigAutofsMap(config.AutofsMapIndirect)),
+		}
+		_, err := NewAutofsMaps(clash)
+		assert.Error(t, err)
+	})
+
+	t.Run("write", func(t *testing.T) {
+		_, autofs := config.SplitAutofsConfigs(configs)
+		maps, err := NewAutofsMaps(autofs)
+		assert.NoError(t, err)
+
+		fsys := vfs.NewMemoryFS()
+		assert.NoError(t, fsys.MkdirAll("/etc", 0755))
+		assert.NoError(t, WriteAutofsMaps(fsys, maps, "/etc", "/etc"))
+		for _, name := range []string{AutofsMasterFile, "auto.cifs", "auto.home", "auto.iso9660", "auto.nfs"} {
+			_, err := fsys.Stat(filepath.Join("/etc", name))
+			assert.NoError(t, err, name)
+		}
+
+		fsys.Fail(vfs.OpRename, "/etc/"+AutofsMasterFile, syscall.EROFS)
+		err = WriteAutofsMaps(fsys, maps, "/etc", "/etc")
+		assert.True(t, errors.Is(err, syscall.EROFS))
+		fsys.ClearFaults()
+
+		// written into an image, auto.master names the maps of the image
+		assert.NoError(t, fsys.MkdirAll("/image/etc", 0755))
+		assert.NoError(t, WriteAutofsMaps(fsys, maps, "/image/etc", "/etc"))
+		master, _ := vfs.ReadFile(fsys, "/image/etc/"+AutofsMasterFile)
+		assert.Equal(t, GenerateAutoMaster(maps, "/etc"), string(master))
+	})
+}
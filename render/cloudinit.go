@@ -0,0 +1,126 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"tienbm90/yml2fstab/config"
+	"tienbm90/yml2fstab/fstab"
+)
+
+const (
+	FormatCloudInit = "cloud-init"
+
+	cloudInitHeader = "#cloud-config\n"
+	// cloudInitSwapMountPoint is the fs_file cloud-init expects for swap entries.
+	cloudInitSwapMountPoint = "none"
+	// cloudInitMountFields is the number of fields of a mounts entry, the fstab columns.
+	cloudInitMountFields = 6
+)
+
+// CloudInitMountDefaultFields are the documented cloud-init defaults, filled in for missing fields.
+var CloudInitMountDefaultFields = []string{"", "", "auto", "defaults,nofail,x-systemd.requires=cloud-init.service", "0", "2"}
+
+// CloudInitRenderer renders a cloud-config fragment with the mounts and mount_default_fields keys.
+type CloudInitRenderer struct{}
+
+func (CloudInitRenderer) Name() string {
+	return FormatCloudInit
+}
+
+// NewCloudInitMount returns the mounts entry of a line, the fstab columns as strings.
+func NewCloudInitMount(line *fstab.FstabLine) []string {
+	mountPoint := line.MountPoint
+	if line.FileSystemType == "swap" {
+		mountPoint = cloudInitSwapMountPoint
+	}
+	return []string{
+		line.Device,
+		mountPoint,
+		line.FileSystemType,
+		line.Options,
+		strconv.Itoa(line.BackupOperation),
+		strconv.Itoa(line.FileSystemCheckOrder),
+	}
+}
+
+// ValidateCloudInitMounts checks the entries against the documented mounts format:
+// one to six non-empty string fields without whitespace, fs_spec first.
+func ValidateCloudInitMounts(mounts [][]string) error {
+	for i, m := range mounts {
+		if len(m) == 0 || len(m) > cloudInitMountFields {
+			return fmt.Errorf("cloud-init mounts entry %d has %d fields, expected 1 to %d", i, len(m), cloudInitMountFields)
+		}
+		for _, field := range m {
+			if field == "" || strings.ContainsAny(field, " \t\n") {
+				return fmt.Errorf("cloud-init mounts entry %d has invalid field %q", i, field)
+			}
+		}
+		// fs_freq and fs_passno
+		for j := 4; j < len(m); j++ {
+			if _, err := strconv.Atoi(m[j]); err != nil {
+				return fmt.Errorf("cloud-init mounts entry %d has non numeric field %q", i, m[j])
+			}
+		}
+	}
+	return nil
+}
+
+func newYamlScalar(value string) *yaml.Node {
+	if value == "" {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+// newYamlFlowSequence renders a list on one line, the way cloud-init documents its mounts.
+func newYamlFlowSequence(values []string) *yaml.Node {
+	seq := &yaml.Node{Kind: yaml.SequenceNode, Style: yaml.FlowStyle}
+	for _, v := range values {
+		seq.Content = append(seq.Content, newYamlScalar(v))
+	}
+	return seq
+}
+
+func (CloudInitRenderer) Render(configs []*config.Config) ([]byte, error) {
+	entries, err := fstab.RenderFstabLines(configs)
+	if err != nil {
+		return nil, err
+	}
+	mounts := make([][]string, 0, len(entries))
+	for _, ent := range entries {
+		mounts = append(mounts, NewCloudInitMount(ent))
+	}
+	if err := ValidateCloudInitMounts(mounts); err != nil {
+		return nil, err
+	}
+
+	mountsNode := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, m := range mounts {
+		mountsNode.Content = append(mountsNode.Content, newYamlFlowSequence(m))
+	}
+	doc := &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{
+		newYamlScalar("mounts"), mountsNode,
+		newYamlScalar("mount_default_fields"), newYamlFlowSequence(CloudInitMountDefaultFields),
+	}}
+
+	var b bytes.Buffer
+	b.WriteString(cloudInitHeader)
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func init() {
+	RegisterConfigRenderer(CloudInitRenderer{})
+}
@@ -0,0 +1,52 @@
+package render
+
+import (
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+	"testing"
+
+	"tienbm90/yml2fstab/config"
+)
+
+func TestCloudInitRenderer(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		configs := []*config.Config{
+			config.NewConfigWithOptions(config.WithConfigSource("/dev/sdb1"), config.WithConfigMount("/var/lib/postgresql"), config.WithConfigFSType("ext4"), config.WithConfigFileSystemCheckOrder(2)),
+			config.NewConfigWithOptions(config.WithConfigSource("192.168.4.5"), config.WithConfigMount("/home"), config.WithConfigExport("/var/nfs/home"),
+				config.WithConfigFSType("nfs"), config.WithConfigOptions([]string{"noexec", "nosuid"})),
+			config.NewConfigWithOptions(config.WithConfigSource("/dev/sda3"), config.WithConfigMount(config.SwapMountPoint), config.WithConfigFSType("swap")),
+		}
+		content, err := CloudInitRenderer{}.Render(configs)
+		assert.NoError(t, err)
+		assert.Equal(t, `#cloud-config
+mounts:
+  - [/dev/sdb1, /var/lib/postgresql, ext4, defaults, "0", "2"]
+  - ['192.168.4.5:/var/nfs/home', /home, nfs, 'noexec,nosuid', "0", "0"]
+  - [/dev/sda3, none, swap, defaults, "0", "0"]
+mount_default_fields: [null, null, auto, 'defaults,nofail,x-systemd.requires=cloud-init.service', "0", "2"]
+`, string(content))
+
+		// every field is read back as a string
+		doc := struct {
+			Mounts             [][]interface{} `yaml:"mounts"`
+			MountDefaultFields []interface{}   `yaml:"mount_default_fields"`
+		}{}
+		assert.NoError(t, yaml.Unmarshal(content, &doc))
+		assert.Equal(t, 3, len(doc.Mounts))
+		for _, m := range doc.Mounts {
+			for _, field := range m {
+				assert.IsType(t, "", field)
+			}
+		}
+		assert.Nil(t, doc.MountDefaultFields[0])
+		assert.Equal(t, 6, len(doc.MountDefaultFields))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		assert.Error(t, ValidateCloudInitMounts([][]string{{}}))
+		assert.Error(t, ValidateCloudInitMounts([][]string{{"a", "b", "c", "d", "0", "0", "x"}}))
+		assert.Error(t, ValidateCloudInitMounts([][]string{{"/dev/sdb1", "/my data"}}))
+		assert.Error(t, ValidateCloudInitMounts([][]string{{"/dev/sdb1", "/data", "ext4", "defaults", "zero"}}))
+		assert.NoError(t, ValidateCloudInitMounts([][]string{{"/dev/sdb1", "/data"}}))
+	})
+}
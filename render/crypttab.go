@@ -0,0 +1,42 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+
+	"tienbm90/yml2fstab/config"
+	"tienbm90/yml2fstab/fstab"
+	"tienbm90/yml2fstab/vfs"
+)
+
+// NewCrypttabEntries returns the crypttab lines of the encrypted entries. A mapper name
+// opened from two different devices is an error, the same volume mounted twice is listed once.
+func NewCrypttabEntries(configs []*config.Config) ([]string, error) {
+	entries := make([]string, 0)
+	opened := make(map[string]*config.EncryptionConfig)
+	for _, c := range configs {
+		e := c.Encryption
+		if e == nil {
+			continue
+		}
+		if other, ok := opened[e.Name]; ok {
+			if other.GenerateCrypttabEntryString() != e.GenerateCrypttabEntryString() {
+				return nil, fmt.Errorf("mapper name %s is used for %s and %s", e.Name, other.Device, e.Device)
+			}
+			continue
+		}
+		opened[e.Name] = e
+		entries = append(entries, e.GenerateCrypttabEntryString())
+	}
+	return entries, nil
+}
+
+// WriteCrypttabFile replaces dst of fsys with the crypttab lines through fstab.WriteFile, dst
+// is left as it was when the write fails. Errors are a *fstab.WriteError.
+func WriteCrypttabFile(fsys vfs.FS, entries []string, dst string) error {
+	var b strings.Builder
+	for _, ent := range entries {
+		b.WriteString(ent + "\n")
+	}
+	return fstab.WriteFile(fsys, dst, []byte(b.String()))
+}
@@ -0,0 +1,127 @@
+package render
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"syscall"
+	"testing"
+
+	"tienbm90/yml2fstab/config"
+	"tienbm90/yml2fstab/fstab"
+	"tienbm90/yml2fstab/validate"
+	"tienbm90/yml2fstab/vfs"
+)
+
+func encryptedEntryData() map[string]interface{} {
+	data := make(map[string]interface{})
+	data["mount"] = "/srv/data"
+	data["type"] = "xfs"
+	data["encryption"] = map[string]interface{}{
+		"device":  "UUID=6e3c1a3c-5b8d-4c4e-9a52-0c2b3f1d9e11",
+		"key":     "/etc/keys/data.key",
+		"options": []interface{}{"discard", "tpm2-device=auto"},
+	}
+	return data
+}
+
+func TestNewConfigFromMapDataEncryption(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		cnf, err := config.NewConfigFromMapData("data", encryptedEntryData())
+		assert.NoError(t, err)
+		assert.NoError(t, validate.ValidateConfig(cnf))
+		assert.Equal(t, "/dev/mapper/data", cnf.GetMountDevice())
+		assert.Equal(t, "data UUID=6e3c1a3c-5b8d-4c4e-9a52-0c2b3f1d9e11 /etc/keys/data.key discard,tpm2-device=auto",
+			cnf.Encryption.GenerateCrypttabEntryString())
+
+		ent, err := fstab.NewFstabLineFromConfig(*cnf)
+		assert.NoError(t, err)
+		assert.Equal(t, "/dev/mapper/data /srv/data xfs defaults 0 0", ent.GenerateFstabEntryString())
+	})
+
+	t.Run("mapper path and passphrase", func(t *testing.T) {
+		data := make(map[string]interface{})
+		data["mount"] = "/srv/data"
+		data["type"] = "ext4"
+		data["encryption"] = map[string]interface{}{"device": "/dev/sdb2"}
+
+		cnf, err := config.NewConfigFromMapData("/dev/mapper/data", data)
+		assert.NoError(t, err)
+		assert.NoError(t, validate.ValidateConfig(cnf))
+		assert.Equal(t, "data /dev/sdb2 none luks", cnf.Encryption.GenerateCrypttabEntryString())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		invalid := []map[string]interface{}{
+			{"key": "/etc/keys/data.key"},
+			{"device": 2},
+			{"device": "/dev/sdb2", "key": "keys/data.key"},
+			{"device": "/dev/sdb2", "options": "discard"},
+			{"device": "/dev/sdb2", "options": []interface{}{"discard,luks"}},
+		}
+		for _, enc := range invalid {
+			data := encryptedEntryData()
+			data["encryption"] = enc
+			cnf, err := config.NewConfigFromMapData("data", data)
+			if err == nil {
+				err = validate.ValidateConfig(cnf)
+			}
+			assert.Error(t, err, enc)
+		}
+
+		data := encryptedEntryData()
+		data["encryption"] = "/dev/sdb2"
+		_, err := config.NewConfigFromMapData("data", data)
+		assert.Error(t, err)
+
+		cnf, err := config.NewConfigFromMapData("my/data", encryptedEntryData())
+		assert.NoError(t, err)
+		assert.Error(t, validate.ValidateConfig(cnf))
+	})
+}
+
+func TestNewCrypttabEntries(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		first, err := config.NewConfigFromMapData("data", encryptedEntryData())
+		assert.NoError(t, err)
+		second, err := config.NewConfigFromMapData("data", encryptedEntryData())
+		assert.NoError(t, err)
+		second.SetMount("/srv/data2")
+		plain := config.NewConfigWithOptions(config.WithConfigSource("/dev/sda2"), config.WithConfigMount("/"), config.WithConfigFSType("ext4"))
+
+		entries, err := NewCrypttabEntries([]*config.Config{plain, first, second})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(entries))
+
+		fsys := vfs.NewMemoryFS()
+		assert.NoError(t, fsys.MkdirAll("/etc", 0755))
+		assert.NoError(t, WriteCrypttabFile(fsys, entries, "/etc/crypttab"))
+		content, err := vfs.ReadFile(fsys, "/etc/crypttab")
+		assert.NoError(t, err)
+		assert.Equal(t, entries[0]+"\n", string(content))
+	})
+
+	t.Run("write failure keeps the crypttab", func(t *testing.T) {
+		fsys := vfs.NewMemoryFS()
+		assert.NoError(t, fsys.MkdirAll("/etc", 0755))
+		assert.NoError(t, vfs.WriteFile(fsys, "/etc/crypttab", []byte("old\n"), 0600))
+		fsys.Fail(vfs.OpWrite, "/etc/.crypttab.tmp-*", syscall.ENOSPC)
+
+		err := WriteCrypttabFile(fsys, []string{"data UUID=6e3c1a3c none luks"}, "/etc/crypttab")
+		assert.True(t, errors.Is(err, fstab.ErrWrite))
+		content, _ := vfs.ReadFile(fsys, "/etc/crypttab")
+		assert.Equal(t, "old\n", string(content))
+		assert.Equal(t, []string{"/etc", "/etc/crypttab"}, fsys.Paths())
+	})
+
+	t.Run("mapper name used twice", func(t *testing.T) {
+		first, err := config.NewConfigFromMapData("data", encryptedEntryData())
+		assert.NoError(t, err)
+		data := encryptedEntryData()
+		data["encryption"] = map[string]interface{}{"device": "/dev/sdc1"}
+		second, err := config.NewConfigFromMapData("data", data)
+		assert.NoError(t, err)
+
+		_, err = NewCrypttabEntries([]*config.Config{first, second})
+		assert.Error(t, err)
+	})
+}
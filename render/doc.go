@@ -0,0 +1,5 @@
+// Package render turns configs and fstab lines into the other outputs of yml2fstab:
+// systemd units, crypttab, autofs maps, cloud-init, Ignition, Kubernetes and NixOS.
+//
+// Renderers fail with an error naming the entry that cannot be represented.
+package render
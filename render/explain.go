@@ -0,0 +1,158 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"tienbm90/yml2fstab/config"
+	"tienbm90/yml2fstab/fstab"
+)
+
+// BootExplanation is what systemd-fstab-generator makes of one fstab line at boot.
+type BootExplanation struct {
+	Mount *SystemdMount
+	// units ordered before this one by systemd on its own, such as the parent mount
+	ImplicitAfter []string
+	Warnings      []string
+}
+
+func (e *BootExplanation) Units() []string {
+	if e.Mount.Automount {
+		return []string{e.Mount.AutomountUnitName(), e.Mount.UnitName()}
+	}
+	return []string{e.Mount.UnitName()}
+}
+
+// Activation describes how the target pulls in the unit.
+func (e *BootExplanation) Activation() string {
+	m := e.Mount
+	switch {
+	case m.NoAuto:
+		return "not started at boot (noauto)"
+	case m.NoFail:
+		return fmt.Sprintf("wanted by %s, boot continues if it fails (nofail)", m.Target)
+	default:
+		return fmt.Sprintf("required by %s, boot fails if it fails", m.Target)
+	}
+}
+
+// parentMount returns the closest entry mounted above where, nil if there is none.
+func parentMount(mounts []*SystemdMount, where string) *SystemdMount {
+	var parent *SystemdMount
+	for _, m := range mounts {
+		if m.Swap || m.Where == where {
+			continue
+		}
+		prefix := strings.TrimSuffix(m.Where, "/") + "/"
+		if !strings.HasPrefix(where, prefix) {
+			continue
+		}
+		if parent == nil || len(m.Where) > len(parent.Where) {
+			parent = m
+		}
+	}
+	return parent
+}
+
+// isRemoteSource reports whether the device is a server export such as host:/path or //host/share.
+func isRemoteSource(device string) bool {
+	return strings.Contains(device, ":/") || strings.HasPrefix(device, "//")
+}
+
+func ExplainBoot(entries []*fstab.FstabLine) []*BootExplanation {
+	mounts := make([]*SystemdMount, 0)
+	for _, ent := range entries {
+		mounts = append(mounts, NewSystemdMountFromFstabLine(ent))
+	}
+
+	explanations := make([]*BootExplanation, 0)
+	for _, m := range mounts {
+		e := &BootExplanation{Mount: m}
+
+		if m.Swap && config.IsSwapFilePath(m.What) {
+			// swapon of a file waits for the filesystem holding it
+			if parent := parentMount(mounts, m.What); parent != nil {
+				e.ImplicitAfter = append(e.ImplicitAfter, parent.UnitName())
+			}
+		} else if !m.Swap {
+			if parent := parentMount(mounts, m.Where); parent != nil {
+				e.ImplicitAfter = append(e.ImplicitAfter, parent.UnitName())
+			}
+		}
+
+		if !m.Swap && m.Target == LocalFSTarget && (config.IsNetworkFileSystem(m.Type) || isRemoteSource(m.What)) {
+			if m.NoAuto || m.NoFail {
+				e.Warnings = append(e.Warnings, fmt.Sprintf("%s is a network filesystem without _netdev, it is ordered with local-fs.target", m.Type))
+			} else {
+				e.Warnings = append(e.Warnings, fmt.Sprintf("%s is a network filesystem without _netdev, it blocks local-fs.target until the network is up", m.Type))
+			}
+		}
+		if m.Automount && m.NoAuto {
+			e.Warnings = append(e.Warnings, "x-systemd.automount with noauto, the automount unit is not started at boot")
+		}
+		explanations = append(explanations, e)
+	}
+	return explanations
+}
+
+func writeRelation(w io.Writer, key string, values []string) error {
+	if len(values) == 0 {
+		return nil
+	}
+	_, err := fmt.Fprintf(w, "  %s=%s\n", key, strings.Join(values, " "))
+	return err
+}
+
+// WriteBootExplanations prints the explanations, warnings are prefixed with "!!" so they stand out.
+func WriteBootExplanations(w io.Writer, explanations []*BootExplanation) error {
+	warnings := 0
+	for _, e := range explanations {
+		m := e.Mount
+		name := m.Where
+		if m.Swap {
+			name = m.What
+		}
+		_, err := fmt.Fprintf(w, "%s: %s\n", name, strings.Join(e.Units(), " -> "))
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "  target: %s, %s\n", m.Target, e.Activation())
+		if err != nil {
+			return err
+		}
+		for _, rel := range []struct {
+			key    string
+			values []string
+		}{
+			{"Requires", m.Requires},
+			{"Wants", m.Wants},
+			{"After", append(append([]string{}, m.After...), e.ImplicitAfter...)},
+			{"Before", m.Before},
+			{"RequiresMountsFor", m.RequiresMountsFor},
+		} {
+			if err := writeRelation(w, rel.key, rel.values); err != nil {
+				return err
+			}
+		}
+		if m.Automount {
+			timeout := m.IdleTimeout
+			if timeout == "" {
+				timeout = "none"
+			}
+			_, err = fmt.Fprintf(w, "  automount: mounted on first access, idle timeout %s\n", timeout)
+			if err != nil {
+				return err
+			}
+		}
+		for _, warning := range e.Warnings {
+			warnings++
+			_, err = fmt.Fprintf(w, "  !! %s\n", warning)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	_, err := fmt.Fprintf(w, "%d entries, %d warnings\n", len(explanations), warnings)
+	return err
+}
@@ -0,0 +1,69 @@
+package render
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+
+	"tienbm90/yml2fstab/config"
+	"tienbm90/yml2fstab/fstab"
+)
+
+func TestExplainBoot(t *testing.T) {
+	t.Run("relations and targets", func(t *testing.T) {
+		entries := []*fstab.FstabLine{
+			fstab.NewFstabEntry("/dev/sda2", "/", "ext4", "defaults", 0, 1),
+			fstab.NewFstabEntry("/dev/sdb2", "/var", "xfs", "defaults", 0, 0),
+			fstab.NewFstabEntry("192.168.4.5:/var/nfs/home", "/home", "nfs", "nofail,x-systemd.automount", 0, 0),
+			fstab.NewFstabEntry("/var/swapfile", config.SwapMountPoint, "swap", "pri=5", 0, 0),
+		}
+		explanations := ExplainBoot(entries)
+		assert.Equal(t, 4, len(explanations))
+
+		assert.Equal(t, LocalFSTarget, explanations[1].Mount.Target)
+		assert.Equal(t, []string{"-.mount"}, explanations[1].ImplicitAfter)
+
+		assert.Equal(t, RemoteFSTarget, explanations[2].Mount.Target)
+		assert.Equal(t, []string{"home.automount", "home.mount"}, explanations[2].Units())
+		assert.Contains(t, explanations[2].Activation(), "nofail")
+
+		assert.Equal(t, SwapTarget, explanations[3].Mount.Target)
+		assert.Equal(t, []string{"var.mount"}, explanations[3].ImplicitAfter)
+
+		for _, e := range explanations {
+			assert.Empty(t, e.Warnings)
+		}
+
+		var b bytes.Buffer
+		assert.NoError(t, WriteBootExplanations(&b, explanations))
+		assert.Contains(t, b.String(), "/var: var.mount\n  target: local-fs.target, required by local-fs.target, boot fails if it fails\n  After=-.mount\n  Before=local-fs.target\n")
+		assert.Contains(t, b.String(), "4 entries, 0 warnings\n")
+	})
+
+	t.Run("network mount blocking local-fs.target", func(t *testing.T) {
+		config.RegisterFileSystemType(config.NewFileSystemType("glusterfs-test", config.WithNetwork()))
+		defer config.DefaultFileSystemRegistry.Unregister("glusterfs-test")
+
+		entries := []*fstab.FstabLine{
+			fstab.NewFstabEntry("gluster1:/volume", "/data", "glusterfs-test", "defaults", 0, 0),
+			fstab.NewFstabEntry("gluster1:/volume", "/data2", "glusterfs-test", "_netdev", 0, 0),
+			fstab.NewFstabEntry("user@host:/srv", "/mnt/sshfs", "fuse.sshfs", "nofail", 0, 0),
+		}
+		explanations := ExplainBoot(entries)
+		assert.Equal(t, 1, len(explanations[0].Warnings))
+		assert.Contains(t, explanations[0].Warnings[0], "blocks local-fs.target")
+		assert.Empty(t, explanations[1].Warnings)
+		assert.Equal(t, RemoteFSTarget, explanations[1].Mount.Target)
+		// systemd drops the fuse. prefix, so fuse.sshfs is remote without _netdev
+		assert.Empty(t, explanations[2].Warnings)
+		assert.Equal(t, RemoteFSTarget, explanations[2].Mount.Target)
+		assert.Contains(t, explanations[2].Mount.Wants, "network-online.target")
+		assert.Contains(t, explanations[2].Mount.After, "network-online.target")
+
+		var b bytes.Buffer
+		assert.NoError(t, WriteBootExplanations(&b, explanations))
+		assert.Contains(t, b.String(), "  !! glusterfs-test is a network filesystem without _netdev, it blocks local-fs.target until the network is up\n")
+		assert.Contains(t, b.String(), "/mnt/sshfs: mnt-sshfs.mount\n  target: remote-fs.target, wanted by remote-fs.target")
+		assert.Contains(t, b.String(), "3 entries, 1 warnings\n")
+	})
+}
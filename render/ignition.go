@@ -0,0 +1,163 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"tienbm90/yml2fstab/config"
+	"tienbm90/yml2fstab/fstab"
+)
+
+const (
+	FormatIgnition = "ignition"
+
+	// IgnitionVersion is the Ignition config spec the output follows.
+	IgnitionVersion = "3.4.0"
+)
+
+// IgnitionFormats are the filesystem formats accepted by storage.filesystems.
+var IgnitionFormats = []string{"ext4", "btrfs", "xfs", "vfat", "swap", "none"}
+
+// IgnitionUnitSuffixes are the unit types the renderer may emit.
+var IgnitionUnitSuffixes = []string{".mount", ".automount", ".swap"}
+
+type IgnitionConfig struct {
+	Ignition IgnitionMeta    `json:"ignition"`
+	Storage  IgnitionStorage `json:"storage"`
+	Systemd  IgnitionSystemd `json:"systemd"`
+}
+
+type IgnitionMeta struct {
+	Version string `json:"version"`
+}
+
+type IgnitionStorage struct {
+	Filesystems []IgnitionFilesystem `json:"filesystems,omitempty"`
+}
+
+// IgnitionFilesystem describes an existing filesystem, it is never wiped or reformatted.
+type IgnitionFilesystem struct {
+	Device         string   `json:"device"`
+	Format         string   `json:"format,omitempty"`
+	Path           string   `json:"path,omitempty"`
+	MountOptions   []string `json:"mountOptions,omitempty"`
+	WipeFilesystem bool     `json:"wipeFilesystem"`
+}
+
+type IgnitionSystemd struct {
+	Units []IgnitionUnit `json:"units,omitempty"`
+}
+
+type IgnitionUnit struct {
+	Name     string `json:"name"`
+	Enabled  *bool  `json:"enabled,omitempty"`
+	Contents string `json:"contents"`
+}
+
+func checkIgnitionFormat(format string) bool {
+	for _, f := range IgnitionFormats {
+		if format == f {
+			return true
+		}
+	}
+	return false
+}
+
+// NewIgnitionFilesystem returns the storage.filesystems entry of a local device, nil for
+// entries Ignition cannot describe such as network mounts. Those are mounted by their unit only.
+func NewIgnitionFilesystem(line *fstab.FstabLine) *IgnitionFilesystem {
+	device := config.DeviceTagToPath(line.Device)
+	if !strings.HasPrefix(device, "/dev/") || !checkIgnitionFormat(line.FileSystemType) {
+		return nil
+	}
+	fs := &IgnitionFilesystem{
+		Device: device,
+		Format: line.FileSystemType,
+	}
+	if line.FileSystemType != "swap" {
+		fs.Path = line.MountPoint
+		fs.MountOptions = fstab.SplitOptions(line.Options)
+	}
+	return fs
+}
+
+// ValidateIgnitionConfig checks the fields Ignition v3 requires of filesystems and units.
+func ValidateIgnitionConfig(cnf *IgnitionConfig) error {
+	for _, fs := range cnf.Storage.Filesystems {
+		if !strings.HasPrefix(fs.Device, "/") {
+			return fmt.Errorf("ignition filesystem device %s must be an absolute path", fs.Device)
+		}
+		if !checkIgnitionFormat(fs.Format) {
+			return fmt.Errorf("ignition filesystem %s has unsupported format %s", fs.Device, fs.Format)
+		}
+		if fs.Path != "" && !strings.HasPrefix(fs.Path, "/") {
+			return fmt.Errorf("ignition filesystem %s path %s must be absolute", fs.Device, fs.Path)
+		}
+	}
+	names := make(map[string]bool)
+	for _, unit := range cnf.Systemd.Units {
+		valid := false
+		for _, suffix := range IgnitionUnitSuffixes {
+			valid = valid || strings.HasSuffix(unit.Name, suffix)
+		}
+		if !valid || strings.Contains(unit.Name, "/") {
+			return fmt.Errorf("invalid ignition unit name %s", unit.Name)
+		}
+		if names[unit.Name] {
+			return fmt.Errorf("ignition unit %s is defined twice", unit.Name)
+		}
+		names[unit.Name] = true
+		if unit.Contents == "" {
+			return fmt.Errorf("ignition unit %s has no contents", unit.Name)
+		}
+	}
+	return nil
+}
+
+// IgnitionRenderer renders an Ignition v3 config with storage.filesystems and the systemd
+// units that mount them, the same units as -format systemd.
+type IgnitionRenderer struct{}
+
+func (IgnitionRenderer) Name() string {
+	return FormatIgnition
+}
+
+func (IgnitionRenderer) Render(configs []*config.Config) ([]byte, error) {
+	entries, err := fstab.RenderFstabLines(configs)
+	if err != nil {
+		return nil, err
+	}
+	units, err := NewSystemdUnits(entries)
+	if err != nil {
+		return nil, err
+	}
+
+	cnf := &IgnitionConfig{Ignition: IgnitionMeta{Version: IgnitionVersion}}
+	for _, ent := range entries {
+		if fs := NewIgnitionFilesystem(ent); fs != nil {
+			cnf.Storage.Filesystems = append(cnf.Storage.Filesystems, *fs)
+		}
+	}
+	for _, unit := range units {
+		u := IgnitionUnit{Name: unit.Name, Contents: unit.Content}
+		if len(unit.WantedBy) > 0 || len(unit.RequiredBy) > 0 {
+			enabled := true
+			u.Enabled = &enabled
+		}
+		cnf.Systemd.Units = append(cnf.Systemd.Units, u)
+	}
+	if err := ValidateIgnitionConfig(cnf); err != nil {
+		return nil, err
+	}
+
+	content, err := json.MarshalIndent(cnf, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(content, '\n'), nil
+}
+
+func init() {
+	RegisterConfigRenderer(IgnitionRenderer{})
+}
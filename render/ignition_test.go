@@ -0,0 +1,59 @@
+package render
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+
+	"tienbm90/yml2fstab/config"
+)
+
+func TestIgnitionRenderer(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		configs := []*config.Config{
+			config.NewConfigWithOptions(config.WithConfigSource("LABEL=data"), config.WithConfigMount("/var/lib/data"), config.WithConfigFSType("xfs"),
+				config.WithConfigOptions([]string{"noatime"})),
+			config.NewConfigWithOptions(config.WithConfigSource("192.168.4.5"), config.WithConfigMount("/home"), config.WithConfigExport("/var/nfs/home"),
+				config.WithConfigFSType("nfs"), config.WithConfigOptions([]string{"noauto"})),
+			config.NewConfigWithOptions(config.WithConfigSource("/dev/sda3"), config.WithConfigMount(config.SwapMountPoint), config.WithConfigFSType("swap")),
+			config.NewConfigWithOptions(config.WithConfigSource("/dev/sr0"), config.WithConfigMount("/media/cdrom"), config.WithConfigFSType("iso9660")),
+		}
+		content, err := IgnitionRenderer{}.Render(configs)
+		assert.NoError(t, err)
+
+		cnf := &IgnitionConfig{}
+		assert.NoError(t, json.Unmarshal(content, cnf))
+		assert.Equal(t, IgnitionVersion, cnf.Ignition.Version)
+
+		assert.Equal(t, []IgnitionFilesystem{
+			{Device: "/dev/disk/by-label/data", Format: "xfs", Path: "/var/lib/data", MountOptions: []string{"noatime"}},
+			{Device: "/dev/sda3", Format: "swap"},
+		}, cnf.Storage.Filesystems)
+
+		names := make([]string, 0)
+		for _, unit := range cnf.Systemd.Units {
+			names = append(names, unit.Name)
+			if unit.Name == "home.mount" {
+				assert.Nil(t, unit.Enabled)
+			} else {
+				assert.True(t, *unit.Enabled)
+			}
+		}
+		assert.Equal(t, []string{"var-lib-data.mount", "home.mount", "dev-sda3.swap", "media-cdrom.mount"}, names)
+		assert.Contains(t, cnf.Systemd.Units[0].Contents, "What=/dev/disk/by-label/data\n")
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		invalid := []*IgnitionConfig{
+			{Storage: IgnitionStorage{Filesystems: []IgnitionFilesystem{{Device: "sda1", Format: "ext4"}}}},
+			{Storage: IgnitionStorage{Filesystems: []IgnitionFilesystem{{Device: "/dev/sda1", Format: "ext3"}}}},
+			{Storage: IgnitionStorage{Filesystems: []IgnitionFilesystem{{Device: "/dev/sda1", Format: "ext4", Path: "data"}}}},
+			{Systemd: IgnitionSystemd{Units: []IgnitionUnit{{Name: "data.service", Contents: "[Unit]"}}}},
+			{Systemd: IgnitionSystemd{Units: []IgnitionUnit{{Name: "data.mount"}}}},
+			{Systemd: IgnitionSystemd{Units: []IgnitionUnit{{Name: "data.mount", Contents: "[Unit]"}, {Name: "data.mount", Contents: "[Unit]"}}}},
+		}
+		for _, cnf := range invalid {
+			assert.Error(t, ValidateIgnitionConfig(cnf))
+		}
+	})
+}
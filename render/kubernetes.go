@@ -0,0 +1,189 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"tienbm90/yml2fstab/config"
+	"tienbm90/yml2fstab/fstab"
+	"tienbm90/yml2fstab/validate"
+)
+
+const (
+	FormatKubernetes = "k8s-pv"
+
+	// DefaultPVCapacity is used when an entry has no capacity, NFS and CIFS do not enforce it.
+	DefaultPVCapacity = "1Gi"
+	// DefaultPVAccessMode is used when an entry has no access modes.
+	DefaultPVAccessMode = "ReadWriteMany"
+	// SMBCSIDriver serves CIFS shares, Kubernetes has no in-tree CIFS volume.
+	SMBCSIDriver = "smb.csi.k8s.io"
+)
+
+// PVFileSystemTypes are the types exported as PersistentVolumes.
+var PVFileSystemTypes = []string{"nfs", "nfs4", "cifs"}
+
+// pvIgnoredOptions only make sense in fstab and are not passed to the kubelet mount.
+var pvIgnoredOptions = []string{"defaults", "auto", "noauto", "nofail", "_netdev"}
+
+var invalidNameCharRegexp = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+type PersistentVolume struct {
+	APIVersion string               `yaml:"apiVersion"`
+	Kind       string               `yaml:"kind"`
+	Metadata   PersistentVolumeMeta `yaml:"metadata"`
+	Spec       PersistentVolumeSpec `yaml:"spec"`
+}
+
+type PersistentVolumeMeta struct {
+	Name   string            `yaml:"name"`
+	Labels map[string]string `yaml:"labels,omitempty"`
+}
+
+type PersistentVolumeSpec struct {
+	Capacity                      map[string]string `yaml:"capacity"`
+	AccessModes                   []string          `yaml:"accessModes"`
+	PersistentVolumeReclaimPolicy string            `yaml:"persistentVolumeReclaimPolicy"`
+	MountOptions                  []string          `yaml:"mountOptions,omitempty"`
+	NFS                           *NFSVolumeSource  `yaml:"nfs,omitempty"`
+	CSI                           *CSIVolumeSource  `yaml:"csi,omitempty"`
+}
+
+type NFSVolumeSource struct {
+	Server   string `yaml:"server"`
+	Path     string `yaml:"path"`
+	ReadOnly bool   `yaml:"readOnly,omitempty"`
+}
+
+type CSIVolumeSource struct {
+	Driver           string            `yaml:"driver"`
+	VolumeHandle     string            `yaml:"volumeHandle"`
+	ReadOnly         bool              `yaml:"readOnly,omitempty"`
+	VolumeAttributes map[string]string `yaml:"volumeAttributes"`
+}
+
+func IsPersistentVolumeType(fsType string) bool {
+	for _, t := range PVFileSystemTypes {
+		if fsType == t {
+			return true
+		}
+	}
+	return false
+}
+
+// PersistentVolumeName derives a DNS-1123 name from the type and mount point, nfs-home for /home.
+func PersistentVolumeName(c *config.Config) string {
+	name := strings.ToLower(c.GetFileSystemType() + "-" + strings.Trim(c.GetMountPoint(), "/"))
+	name = invalidNameCharRegexp.ReplaceAllString(strings.ReplaceAll(name, "/", "-"), "-")
+	return strings.Trim(name, "-.")
+}
+
+func NewPersistentVolume(c *config.Config) (*PersistentVolume, error) {
+	capacity := c.Capacity
+	if capacity == "" {
+		capacity = DefaultPVCapacity
+	}
+	accessModes := c.AccessModes
+	if len(accessModes) == 0 {
+		accessModes = []string{DefaultPVAccessMode}
+	}
+
+	readOnly := false
+	options := make([]string, 0)
+	for _, opt := range c.GetOptions() {
+		ignored := strings.HasPrefix(opt, fstab.SystemdOptionPrefix)
+		for _, o := range pvIgnoredOptions {
+			ignored = ignored || opt == o
+		}
+		if opt == "ro" {
+			readOnly = true
+		}
+		if !ignored {
+			options = append(options, opt)
+		}
+	}
+	if c.GetFileSystemType() == "nfs4" {
+		options = append(options, "nfsvers=4")
+	}
+
+	pv := &PersistentVolume{
+		APIVersion: "v1",
+		Kind:       "PersistentVolume",
+		Metadata: PersistentVolumeMeta{
+			Name:   PersistentVolumeName(c),
+			Labels: map[string]string{"app.kubernetes.io/managed-by": "yml2fstab"},
+		},
+		Spec: PersistentVolumeSpec{
+			Capacity:                      map[string]string{"storage": capacity},
+			AccessModes:                   accessModes,
+			PersistentVolumeReclaimPolicy: "Retain",
+			MountOptions:                  options,
+		},
+	}
+	if c.GetFileSystemType() == "cifs" {
+		source, err := config.RenderMountDevice(c)
+		if err != nil {
+			return nil, err
+		}
+		pv.Spec.CSI = &CSIVolumeSource{
+			Driver:           SMBCSIDriver,
+			VolumeHandle:     strings.TrimPrefix(source, "//") + "#" + pv.Metadata.Name,
+			ReadOnly:         readOnly,
+			VolumeAttributes: map[string]string{"source": source},
+		}
+	} else {
+		pv.Spec.NFS = &NFSVolumeSource{
+			Server:   c.Source,
+			Path:     c.Export,
+			ReadOnly: readOnly,
+		}
+	}
+	return pv, nil
+}
+
+// KubernetesRenderer renders a PersistentVolume manifest per network entry, other entries are skipped.
+type KubernetesRenderer struct{}
+
+func (KubernetesRenderer) Name() string {
+	return FormatKubernetes
+}
+
+func (KubernetesRenderer) Render(configs []*config.Config) ([]byte, error) {
+	var b bytes.Buffer
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+
+	names := make(map[string]string)
+	for _, c := range configs {
+		if !IsPersistentVolumeType(c.GetFileSystemType()) {
+			continue
+		}
+		if err := validate.ValidatePersistentVolumeConfig(c); err != nil {
+			return nil, err
+		}
+		pv, err := NewPersistentVolume(c)
+		if err != nil {
+			return nil, err
+		}
+		if other, ok := names[pv.Metadata.Name]; ok {
+			return nil, fmt.Errorf("%s and %s map to the same PersistentVolume %s", other, c.GetMountPoint(), pv.Metadata.Name)
+		}
+		names[pv.Metadata.Name] = c.GetMountPoint()
+
+		if err := enc.Encode(pv); err != nil {
+			return nil, err
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func init() {
+	RegisterConfigRenderer(KubernetesRenderer{})
+}
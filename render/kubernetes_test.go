@@ -0,0 +1,129 @@
+package render
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+
+	"tienbm90/yml2fstab/config"
+	"tienbm90/yml2fstab/validate"
+)
+
+func TestNewConfigFromMapDataPersistentVolume(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		data := make(map[string]interface{})
+		data["mount"] = "/home"
+		data["export"] = "/var/nfs/home"
+		data["type"] = "nfs"
+		data["capacity"] = "100Gi"
+		data["access-modes"] = []interface{}{"ReadWriteMany", "ReadOnlyMany"}
+
+		cnf, err := config.NewConfigFromMapData("192.168.4.5", data)
+		assert.NoError(t, err)
+		assert.NoError(t, validate.ValidateConfig(cnf))
+		assert.Equal(t, "100Gi", cnf.Capacity)
+		assert.Equal(t, []string{"ReadWriteMany", "ReadOnlyMany"}, cnf.AccessModes)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		invalid := []map[string]interface{}{
+			{"capacity": "a lot"},
+			{"access-modes": []interface{}{"ReadWriteSometimes"}},
+		}
+		for _, fields := range invalid {
+			data := map[string]interface{}{"mount": "/home", "export": "/var/nfs/home", "type": "nfs"}
+			for k, v := range fields {
+				data[k] = v
+			}
+			cnf, err := config.NewConfigFromMapData("192.168.4.5", data)
+			assert.NoError(t, err)
+			assert.Error(t, validate.ValidateConfig(cnf))
+		}
+
+		_, err := config.NewConfigFromMapData("192.168.4.5", map[string]interface{}{"mount": "/home", "type": "nfs", "capacity": 100})
+		assert.Error(t, err)
+		_, err = config.NewConfigFromMapData("192.168.4.5", map[string]interface{}{"mount": "/home", "type": "nfs", "access-modes": "ReadWriteMany"})
+		assert.Error(t, err)
+	})
+}
+
+func TestKubernetesRenderer(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		configs := []*config.Config{
+			config.NewConfigWithOptions(config.WithConfigSource("/dev/sda2"), config.WithConfigMount("/"), config.WithConfigFSType("ext4")),
+			config.NewConfigWithOptions(config.WithConfigSource("192.168.4.5"), config.WithConfigMount("/home"), config.WithConfigExport("/var/nfs/home"),
+				config.WithConfigFSType("nfs"), config.WithConfigOptions([]string{"noexec", "nosuid", "_netdev"}), config.WithConfigCapacity("100Gi")),
+			config.NewConfigWithOptions(config.WithConfigSource("nas.example.com"), config.WithConfigMount("/srv/Archive"), config.WithConfigExport("/archive"),
+				config.WithConfigFSType("nfs4"), config.WithConfigOptions([]string{"ro"}), config.WithConfigAccessModes([]string{"ReadOnlyMany"})),
+			config.NewConfigWithOptions(config.WithConfigSource("fileserver"), config.WithConfigMount("/srv/public"), config.WithConfigExport("/public"),
+				config.WithConfigFSType("cifs")),
+		}
+		content, err := KubernetesRenderer{}.Render(configs)
+		assert.NoError(t, err)
+		assert.Equal(t, `apiVersion: v1
+kind: PersistentVolume
+metadata:
+  name: nfs-home
+  labels:
+    app.kubernetes.io/managed-by: yml2fstab
+spec:
+  capacity:
+    storage: 100Gi
+  accessModes:
+    - ReadWriteMany
+  persistentVolumeReclaimPolicy: Retain
+  mountOptions:
+    - noexec
+    - nosuid
+  nfs:
+    server: 192.168.4.5
+    path: /var/nfs/home
+---
+apiVersion: v1
+kind: PersistentVolume
+metadata:
+  name: nfs4-srv-archive
+  labels:
+    app.kubernetes.io/managed-by: yml2fstab
+spec:
+  capacity:
+    storage: 1Gi
+  accessModes:
+    - ReadOnlyMany
+  persistentVolumeReclaimPolicy: Retain
+  mountOptions:
+    - ro
+    - nfsvers=4
+  nfs:
+    server: nas.example.com
+    path: /archive
+    readOnly: true
+---
+apiVersion: v1
+kind: PersistentVolume
+metadata:
+  name: cifs-srv-public
+  labels:
+    app.kubernetes.io/managed-by: yml2fstab
+spec:
+  capacity:
+    storage: 1Gi
+  accessModes:
+    - ReadWriteMany
+  persistentVolumeReclaimPolicy: Retain
+  csi:
+    driver: smb.csi.k8s.io
+    volumeHandle: fileserver/public#cifs-srv-public
+    volumeAttributes:
+      source: //fileserver/public
+`, string(content))
+	})
+
+	t.Run("name clash", func(t *testing.T) {
+		configs := []*config.Config{
+			config.NewConfigWithOptions(config.WithConfigSource("192.168.4.5"), config.WithConfigMount("/srv/a-b"), config.WithConfigExport("/a"), config.WithConfigFSType("nfs")),
+			config.NewConfigWithOptions(config.WithConfigSource("192.168.4.5"), config.WithConfigMount("/srv/a/b"), config.WithConfigExport("/b"), config.WithConfigFSType("nfs")),
+		}
+		_, err := KubernetesRenderer{}.Render(configs)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,129 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"tienbm90/yml2fstab/config"
+	"tienbm90/yml2fstab/fstab"
+)
+
+const (
+	FormatNixOS = "nixos"
+
+	nixosHeader = "# Generated by yml2fstab, import it from configuration.nix.\n"
+)
+
+// NixOSDiscardPolicies maps the swap discard policies to swapDevices.*.discardPolicy.
+var NixOSDiscardPolicies = map[string]string{
+	"all":   "both",
+	"once":  "once",
+	"pages": "pages",
+}
+
+// nixStringEscaper escapes the characters with a meaning inside a double quoted Nix string,
+// ${ would start an interpolation.
+var nixStringEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`"`, `\"`,
+	"${", `\${`,
+	"\n", `\n`,
+	"\r", `\r`,
+	"\t", `\t`,
+)
+
+// NixString returns s as a double quoted Nix string.
+func NixString(s string) string {
+	return `"` + nixStringEscaper.Replace(s) + `"`
+}
+
+// NixStringList returns a Nix list of strings, [ "a" "b" ].
+func NixStringList(values []string) string {
+	if len(values) == 0 {
+		return "[ ]"
+	}
+	items := make([]string, 0, len(values))
+	for _, v := range values {
+		items = append(items, NixString(v))
+	}
+	return "[ " + strings.Join(items, " ") + " ]"
+}
+
+// NixOSRenderer renders a NixOS module with a fileSystems attribute per mount point
+// and the swapDevices list.
+type NixOSRenderer struct{}
+
+func (NixOSRenderer) Name() string {
+	return FormatNixOS
+}
+
+// writeNixOSFileSystem writes the fileSystems attribute of a rendered line. noCheck is set
+// when the entry disables fsck for a type NixOS would check.
+func writeNixOSFileSystem(b *bytes.Buffer, c *config.Config, line *fstab.FstabLine) {
+	fmt.Fprintf(b, "  fileSystems.%s = {\n", NixString(line.MountPoint))
+	fmt.Fprintf(b, "    device = %s;\n", NixString(line.Device))
+	fmt.Fprintf(b, "    fsType = %s;\n", NixString(line.FileSystemType))
+	if options := fstab.SplitOptions(line.Options); len(options) > 0 {
+		fmt.Fprintf(b, "    options = %s;\n", NixStringList(options))
+	}
+	if line.FileSystemCheckOrder == 0 && config.DefaultFileSystemPass(c) > 0 {
+		b.WriteString("    noCheck = true;\n")
+	}
+	b.WriteString("  };\n")
+}
+
+// writeNixOSSwapDevice writes a swapDevices entry, NixOS expects a path instead of a device tag.
+func writeNixOSSwapDevice(b *bytes.Buffer, c *config.Config, line *fstab.FstabLine) {
+	fmt.Fprintf(b, "    {\n      device = %s;\n", NixString(config.DeviceTagToPath(line.Device)))
+	if c.Swap != nil {
+		if c.Swap.Priority != config.SwapPriorityDefault {
+			fmt.Fprintf(b, "      priority = %d;\n", c.Swap.Priority)
+		}
+		if policy, ok := NixOSDiscardPolicies[c.Swap.Discard]; ok {
+			fmt.Fprintf(b, "      discardPolicy = %s;\n", NixString(policy))
+		}
+		if c.Swap.NoFail {
+			fmt.Fprintf(b, "      options = %s;\n", NixStringList([]string{"nofail"}))
+		}
+	}
+	b.WriteString("    }\n")
+}
+
+func (NixOSRenderer) Render(configs []*config.Config) ([]byte, error) {
+	entries, err := fstab.RenderFstabLines(configs)
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	b.WriteString(nixosHeader)
+	b.WriteString("{ ... }:\n\n{\n")
+	swaps := make([]int, 0)
+	mounts := make(map[string]bool)
+	for i, ent := range entries {
+		if configs[i].IsSwap() {
+			swaps = append(swaps, i)
+			continue
+		}
+		// an attribute defined twice does not evaluate
+		if mounts[ent.MountPoint] {
+			return nil, fmt.Errorf("fileSystems.%s is defined twice", NixString(ent.MountPoint))
+		}
+		mounts[ent.MountPoint] = true
+		writeNixOSFileSystem(&b, configs[i], ent)
+	}
+	if len(swaps) > 0 {
+		b.WriteString("  swapDevices = [\n")
+		for _, i := range swaps {
+			writeNixOSSwapDevice(&b, configs[i], entries[i])
+		}
+		b.WriteString("  ];\n")
+	}
+	b.WriteString("}\n")
+	return b.Bytes(), nil
+}
+
+func init() {
+	RegisterConfigRenderer(NixOSRenderer{})
+}
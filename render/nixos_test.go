@@ -0,0 +1,79 @@
+package render
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+
+	"tienbm90/yml2fstab/config"
+)
+
+func TestNixString(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		assert.Equal(t, `"/home"`, NixString("/home"))
+		assert.Equal(t, `"a\"b"`, NixString(`a"b`))
+		assert.Equal(t, `"a\\b"`, NixString(`a\b`))
+		assert.Equal(t, `"\${HOME}/$x"`, NixString("${HOME}/$x"))
+		assert.Equal(t, `"a\nb\tc"`, NixString("a\nb\tc"))
+		assert.Equal(t, `[ ]`, NixStringList(nil))
+		assert.Equal(t, `[ "ro" "x=\"y\"" ]`, NixStringList([]string{"ro", `x="y"`}))
+	})
+}
+
+func TestNixOSRenderer(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		configs := []*config.Config{
+			config.NewConfigWithOptions(config.WithConfigSource("UUID=5e1a"), config.WithConfigMount("/"), config.WithConfigFSType("ext4"), config.WithConfigFileSystemCheckOrder(1)),
+			config.NewConfigWithOptions(config.WithConfigSource("/dev/sdb1"), config.WithConfigMount("/srv/${data}"), config.WithConfigFSType("ext4"),
+				config.WithConfigOptions([]string{"noatime"})),
+			config.NewConfigWithOptions(config.WithConfigSource("192.168.4.5"), config.WithConfigMount("/home"), config.WithConfigExport("/var/nfs/home"),
+				config.WithConfigFSType("nfs"), config.WithConfigOptions([]string{"noexec", "nosuid"})),
+			config.NewConfigWithOptions(config.WithConfigSource("LABEL=swap"), config.WithConfigMount(config.SwapMountPoint), config.WithConfigFSType("swap"),
+				config.WithConfigSwap(&config.SwapConfig{Priority: 10, Discard: "all", NoFail: true})),
+			config.NewConfigWithOptions(config.WithConfigSource("/swapfile"), config.WithConfigMount(config.SwapMountPoint), config.WithConfigFSType("swap"),
+				config.WithConfigSwap(&config.SwapConfig{Priority: config.SwapPriorityDefault})),
+		}
+		content, err := NixOSRenderer{}.Render(configs)
+		assert.NoError(t, err)
+		assert.Equal(t, `# Generated by yml2fstab, import it from configuration.nix.
+{ ... }:
+
+{
+  fileSystems."/" = {
+    device = "UUID=5e1a";
+    fsType = "ext4";
+  };
+  fileSystems."/srv/\${data}" = {
+    device = "/dev/sdb1";
+    fsType = "ext4";
+    options = [ "noatime" ];
+    noCheck = true;
+  };
+  fileSystems."/home" = {
+    device = "192.168.4.5:/var/nfs/home";
+    fsType = "nfs";
+    options = [ "noexec" "nosuid" ];
+  };
+  swapDevices = [
+    {
+      device = "/dev/disk/by-label/swap";
+      priority = 10;
+      discardPolicy = "both";
+      options = [ "nofail" ];
+    }
+    {
+      device = "/swapfile";
+    }
+  ];
+}
+`, string(content))
+	})
+
+	t.Run("duplicate mount point", func(t *testing.T) {
+		configs := []*config.Config{
+			config.NewConfigWithOptions(config.WithConfigSource("/dev/sdb1"), config.WithConfigMount("/data"), config.WithConfigFSType("ext4")),
+			config.NewConfigWithOptions(config.WithConfigSource("/dev/sdc1"), config.WithConfigMount("/data"), config.WithConfigFSType("xfs")),
+		}
+		_, err := NixOSRenderer{}.Render(configs)
+		assert.Error(t, err)
+	})
+}
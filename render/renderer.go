@@ -0,0 +1,33 @@
+package render
+
+import (
+	"sort"
+
+	"tienbm90/yml2fstab/config"
+)
+
+// ConfigRenderer renders all entries into one document, such as a cloud-init fragment.
+type ConfigRenderer interface {
+	Name() string
+	Render(configs []*config.Config) ([]byte, error)
+}
+
+var ConfigRenderers = make(map[string]ConfigRenderer)
+
+func RegisterConfigRenderer(r ConfigRenderer) {
+	ConfigRenderers[r.Name()] = r
+}
+
+func LookupConfigRenderer(name string) (ConfigRenderer, bool) {
+	r, ok := ConfigRenderers[name]
+	return r, ok
+}
+
+func ConfigRendererNames() []string {
+	names := make([]string, 0, len(ConfigRenderers))
+	for name := range ConfigRenderers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
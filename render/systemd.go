@@ -0,0 +1,352 @@
+package render
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"tienbm90/yml2fstab/config"
+	"tienbm90/yml2fstab/fstab"
+	"tienbm90/yml2fstab/vfs"
+)
+
+const (
+	LocalFSTarget  = "local-fs.target"
+	RemoteFSTarget = "remote-fs.target"
+	SwapTarget     = "swap.target"
+
+	FormatSystemd = "systemd"
+)
+
+// SystemdNetworkFileSystems are the types systemd treats as remote even without _netdev.
+var SystemdNetworkFileSystems = []string{"afs", "ceph", "cifs", "smb3", "smbfs", "sshfs", "ncpfs",
+	"ncp", "nfs", "nfs4", "gfs", "gfs2", "glusterfs", "pvfs2", "ocfs2", "lustre", "davfs"}
+
+// SystemdEscapePath escapes a path into a unit name prefix following `systemd-escape --path`.
+func SystemdEscapePath(path string) string {
+	parts := make([]string, 0)
+	for _, p := range strings.Split(path, "/") {
+		if p == "" || p == "." {
+			continue
+		}
+		parts = append(parts, p)
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+
+	var b strings.Builder
+	s := strings.Join(parts, "/")
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '/':
+			b.WriteByte('-')
+		case c == '.' && i == 0:
+			fmt.Fprintf(&b, "\\x%02x", c)
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == ':', c == '_', c == '.':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "\\x%02x", c)
+		}
+	}
+	return b.String()
+}
+
+// IsSystemdNetworkFileSystem follows systemd's fstype_is_network, which drops a leading
+// "fuse." so fuse.sshfs is as remote as sshfs.
+func IsSystemdNetworkFileSystem(fsType string) bool {
+	fsType = strings.TrimPrefix(fsType, "fuse.")
+	for _, t := range SystemdNetworkFileSystems {
+		if fsType == t {
+			return true
+		}
+	}
+	return false
+}
+
+// SystemdMount is the systemd view of one fstab line, interpreted the way systemd-fstab-generator does.
+type SystemdMount struct {
+	What    string
+	Where   string
+	Type    string
+	Options string
+
+	Requires          []string
+	Wants             []string
+	After             []string
+	Before            []string
+	RequiresMountsFor []string
+	WantedBy          []string
+	RequiredBy        []string
+
+	Swap         bool
+	Priority     string
+	NoFail       bool
+	NoAuto       bool
+	Network      bool
+	Automount    bool
+	IdleTimeout  string
+	MountTimeout string
+	Target       string
+}
+
+func NewSystemdMountFromFstabLine(line *fstab.FstabLine) *SystemdMount {
+	m := &SystemdMount{
+		What:  config.DeviceTagToPath(line.Device),
+		Where: line.MountPoint,
+		Type:  line.FileSystemType,
+		Swap:  line.FileSystemType == "swap",
+	}
+
+	options := make([]string, 0)
+	for _, opt := range strings.Split(line.Options, ",") {
+		key, value := opt, ""
+		if i := strings.Index(opt, "="); i >= 0 {
+			key, value = opt[:i], opt[i+1:]
+		}
+		switch key {
+		case "nofail":
+			m.NoFail = true
+		case "noauto":
+			m.NoAuto = true
+		case "_netdev":
+			m.Network = true
+		case "pri":
+			if m.Swap {
+				m.Priority = value
+				continue
+			}
+		case "x-systemd.automount":
+			m.Automount = !m.Swap
+		case "x-systemd.idle-timeout":
+			m.IdleTimeout = value
+		case "x-systemd.mount-timeout":
+			m.MountTimeout = value
+		case "x-systemd.requires":
+			// a path is a mount dependency, anything else a unit
+			if strings.HasPrefix(value, "/") {
+				m.RequiresMountsFor = append(m.RequiresMountsFor, value)
+			} else {
+				m.Requires = append(m.Requires, value)
+				m.After = append(m.After, value)
+			}
+		case "x-systemd.requires-mounts-for":
+			m.RequiresMountsFor = append(m.RequiresMountsFor, value)
+		case "x-systemd.after":
+			m.After = append(m.After, value)
+		case "x-systemd.before":
+			m.Before = append(m.Before, value)
+		case "x-systemd.wanted-by":
+			m.WantedBy = append(m.WantedBy, value)
+		case "x-systemd.required-by":
+			m.RequiredBy = append(m.RequiredBy, value)
+		}
+		if opt != "" && !strings.HasPrefix(key, fstab.SystemdOptionPrefix) {
+			options = append(options, opt)
+		}
+	}
+	m.Options = config.BuildStringFromSlice(options)
+	if !m.Network {
+		m.Network = IsSystemdNetworkFileSystem(m.Type)
+	}
+
+	switch {
+	case m.Swap:
+		m.Target = SwapTarget
+	case m.Network:
+		m.Target = RemoteFSTarget
+		m.After = append(m.After, "network-online.target")
+		m.Wants = append(m.Wants, "network-online.target")
+	default:
+		m.Target = LocalFSTarget
+	}
+
+	// the target waits for the unit unless failures are tolerated
+	if !m.NoAuto {
+		if m.NoFail {
+			m.WantedBy = append(m.WantedBy, m.Target)
+		} else {
+			m.RequiredBy = append(m.RequiredBy, m.Target)
+		}
+	}
+	// with an automount the automount unit is ordered before the target instead
+	if !m.NoFail && !m.Automount {
+		m.Before = append(m.Before, m.Target)
+	}
+	return m
+}
+
+func (m *SystemdMount) UnitName() string {
+	if m.Swap {
+		return SystemdEscapePath(m.What) + ".swap"
+	}
+	return SystemdEscapePath(m.Where) + ".mount"
+}
+
+func (m *SystemdMount) AutomountUnitName() string {
+	return SystemdEscapePath(m.Where) + ".automount"
+}
+
+// InstalledUnitName is the unit the targets pull in, the automount unit when there is one.
+func (m *SystemdMount) InstalledUnitName() string {
+	if m.Automount {
+		return m.AutomountUnitName()
+	}
+	return m.UnitName()
+}
+
+type systemdUnitWriter struct {
+	b strings.Builder
+}
+
+func (w *systemdUnitWriter) section(name string) {
+	fmt.Fprintf(&w.b, "\n[%s]\n", name)
+}
+
+func (w *systemdUnitWriter) set(key string, values ...string) {
+	for _, v := range values {
+		if v != "" {
+			fmt.Fprintf(&w.b, "%s=%s\n", key, v)
+		}
+	}
+}
+
+func (w *systemdUnitWriter) header() {
+	w.b.WriteString("# Automatically generated by yml2fstab\n")
+	w.section("Unit")
+	w.set("Documentation", "man:fstab(5) man:systemd-fstab-generator(8)")
+}
+
+func (w *systemdUnitWriter) install(wantedBy []string, requiredBy []string) {
+	if len(wantedBy) == 0 && len(requiredBy) == 0 {
+		return
+	}
+	w.section("Install")
+	w.set("WantedBy", wantedBy...)
+	w.set("RequiredBy", requiredBy...)
+}
+
+// MountUnit renders the .mount unit, or the .swap unit for swap entries.
+func (m *SystemdMount) MountUnit() string {
+	w := &systemdUnitWriter{}
+	w.header()
+	w.set("Requires", m.Requires...)
+	w.set("Wants", m.Wants...)
+	w.set("After", m.After...)
+	w.set("Before", m.Before...)
+	w.set("RequiresMountsFor", m.RequiresMountsFor...)
+
+	if m.Swap {
+		w.section("Swap")
+		w.set("What", m.What)
+		w.set("Priority", m.Priority)
+		if m.Options != "defaults" {
+			w.set("Options", m.Options)
+		}
+	} else {
+		w.section("Mount")
+		w.set("What", m.What)
+		w.set("Where", m.Where)
+		w.set("Type", m.Type)
+		w.set("Options", m.Options)
+		w.set("TimeoutSec", m.MountTimeout)
+	}
+
+	if !m.Automount {
+		w.install(m.WantedBy, m.RequiredBy)
+	}
+	return w.b.String()
+}
+
+// AutomountUnit renders the .automount unit, it takes over the install wiring of the mount.
+func (m *SystemdMount) AutomountUnit() string {
+	w := &systemdUnitWriter{}
+	w.header()
+	if !m.NoFail {
+		w.set("Before", m.Target)
+	}
+
+	w.section("Automount")
+	w.set("Where", m.Where)
+	w.set("TimeoutIdleSec", m.IdleTimeout)
+
+	w.install(m.WantedBy, m.RequiredBy)
+	return w.b.String()
+}
+
+type SystemdUnitFile struct {
+	Name       string
+	Content    string
+	WantedBy   []string
+	RequiredBy []string
+}
+
+func NewSystemdUnits(entries []*fstab.FstabLine) ([]*SystemdUnitFile, error) {
+	units := make([]*SystemdUnitFile, 0)
+	names := make(map[string]string)
+	for _, ent := range entries {
+		m := NewSystemdMountFromFstabLine(ent)
+		if other, ok := names[m.UnitName()]; ok {
+			return nil, fmt.Errorf("%s and %s map to the same unit %s", other, ent.Device, m.UnitName())
+		}
+		names[m.UnitName()] = ent.Device
+
+		unit := &SystemdUnitFile{Name: m.UnitName(), Content: m.MountUnit()}
+		units = append(units, unit)
+		if m.Automount {
+			unit = &SystemdUnitFile{Name: m.AutomountUnitName(), Content: m.AutomountUnit()}
+			units = append(units, unit)
+		}
+		unit.WantedBy = m.WantedBy
+		unit.RequiredBy = m.RequiredBy
+	}
+	return units, nil
+}
+
+// WriteSystemdUnits writes the units into dir and enables them the way `systemctl enable`
+// would, with .wants and .requires symlinks next to the units.
+func WriteSystemdUnits(fsys vfs.FS, units []*SystemdUnitFile, dir string) error {
+	err := fsys.MkdirAll(dir, 0755)
+	if err != nil {
+		return &fstab.WriteError{Path: dir, Err: err}
+	}
+	for _, unit := range units {
+		err := fstab.WriteFile(fsys, filepath.Join(dir, unit.Name), []byte(unit.Content))
+		if err != nil {
+			return err
+		}
+		for _, target := range unit.WantedBy {
+			if err := linkSystemdUnit(fsys, dir, target+".wants", unit.Name); err != nil {
+				return err
+			}
+		}
+		for _, target := range unit.RequiredBy {
+			if err := linkSystemdUnit(fsys, dir, target+".requires", unit.Name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func linkSystemdUnit(fsys vfs.FS, dir string, depDir string, name string) error {
+	if err := symlinkSystemdUnit(fsys, dir, depDir, name); err != nil {
+		return &fstab.WriteError{Path: filepath.Join(dir, depDir, name), Err: err}
+	}
+	return nil
+}
+
+func symlinkSystemdUnit(fsys vfs.FS, dir string, depDir string, name string) error {
+	err := fsys.MkdirAll(filepath.Join(dir, depDir), 0755)
+	if err != nil {
+		return err
+	}
+	link := filepath.Join(dir, depDir, name)
+	err = fsys.Remove(link)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return fsys.Symlink(filepath.Join("..", name), link)
+}
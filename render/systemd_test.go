@@ -0,0 +1,153 @@
+package render
+
+import (
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"testing"
+
+	"tienbm90/yml2fstab/config"
+	"tienbm90/yml2fstab/fstab"
+	"tienbm90/yml2fstab/vfs"
+)
+
+func TestSystemdEscapePath(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		assert.Equal(t, "-", SystemdEscapePath("/"))
+		assert.Equal(t, "home", SystemdEscapePath("/home"))
+		assert.Equal(t, "var-lib-postgresql", SystemdEscapePath("/var/lib/postgresql"))
+		assert.Equal(t, "var-lib-postgresql", SystemdEscapePath("//var//lib/./postgresql/"))
+		assert.Equal(t, "mnt-my\\x2ddisk", SystemdEscapePath("/mnt/my-disk"))
+		assert.Equal(t, "mnt-my\\x20disk", SystemdEscapePath("/mnt/my disk"))
+		assert.Equal(t, "\\x2esnapshots", SystemdEscapePath("/.snapshots"))
+		assert.Equal(t, "srv-a.b:c_d", SystemdEscapePath("/srv/a.b:c_d"))
+		assert.Equal(t, "dev-disk-by\\x2duuid-0a34", SystemdEscapePath("/dev/disk/by-uuid/0a34"))
+	})
+}
+
+func TestNewSystemdMountFromFstabLine(t *testing.T) {
+	t.Run("local mount", func(t *testing.T) {
+		m := NewSystemdMountFromFstabLine(fstab.NewFstabEntry("UUID=0a34", "/var/lib/postgresql", "ext4", "noatime", 0, 2))
+		assert.Equal(t, "var-lib-postgresql.mount", m.UnitName())
+		assert.Equal(t, LocalFSTarget, m.Target)
+		assert.Equal(t, `# Automatically generated by yml2fstab
+
+[Unit]
+Documentation=man:fstab(5) man:systemd-fstab-generator(8)
+Before=local-fs.target
+
+[Mount]
+What=/dev/disk/by-uuid/0a34
+Where=/var/lib/postgresql
+Type=ext4
+Options=noatime
+
+[Install]
+RequiredBy=local-fs.target
+`, m.MountUnit())
+	})
+
+	t.Run("fuse network mount", func(t *testing.T) {
+		m := NewSystemdMountFromFstabLine(fstab.NewFstabEntry("user@host:/srv", "/mnt/sshfs", "fuse.sshfs", "defaults", 0, 0))
+		assert.Equal(t, RemoteFSTarget, m.Target)
+		assert.Contains(t, m.Wants, "network-online.target")
+		assert.Contains(t, m.After, "network-online.target")
+		assert.False(t, IsSystemdNetworkFileSystem("fuse.s3fs"))
+	})
+
+	t.Run("network mount with systemd options", func(t *testing.T) {
+		opts := "nofail,x-systemd.requires=vpn.service,x-systemd.requires=/srv,x-systemd.mount-timeout=30,noexec"
+		m := NewSystemdMountFromFstabLine(fstab.NewFstabEntry("192.168.4.5:/var/nfs/home", "/home", "nfs", opts, 0, 0))
+		assert.Equal(t, RemoteFSTarget, m.Target)
+		assert.Equal(t, `# Automatically generated by yml2fstab
+
+[Unit]
+Documentation=man:fstab(5) man:systemd-fstab-generator(8)
+Requires=vpn.service
+Wants=network-online.target
+After=vpn.service
+After=network-online.target
+RequiresMountsFor=/srv
+
+[Mount]
+What=192.168.4.5:/var/nfs/home
+Where=/home
+Type=nfs
+Options=nofail,noexec
+TimeoutSec=30
+
+[Install]
+WantedBy=remote-fs.target
+`, m.MountUnit())
+	})
+
+	t.Run("automount", func(t *testing.T) {
+		m := NewSystemdMountFromFstabLine(fstab.NewFstabEntry("/dev/sdc1", "/mnt/usb", "vfat", "x-systemd.automount,x-systemd.idle-timeout=60", 0, 0))
+		assert.True(t, m.Automount)
+		assert.Equal(t, "mnt-usb.automount", m.InstalledUnitName())
+		assert.NotContains(t, m.MountUnit(), "[Install]")
+		assert.NotContains(t, m.MountUnit(), "Before=")
+		assert.Equal(t, `# Automatically generated by yml2fstab
+
+[Unit]
+Documentation=man:fstab(5) man:systemd-fstab-generator(8)
+Before=local-fs.target
+
+[Automount]
+Where=/mnt/usb
+TimeoutIdleSec=60
+
+[Install]
+RequiredBy=local-fs.target
+`, m.AutomountUnit())
+	})
+
+	t.Run("noauto is not installed", func(t *testing.T) {
+		m := NewSystemdMountFromFstabLine(fstab.NewFstabEntry("/dev/sr0", "/media/cdrom", "iso9660", "noauto,ro", 0, 0))
+		assert.NotContains(t, m.MountUnit(), "[Install]")
+	})
+
+	t.Run("swap", func(t *testing.T) {
+		m := NewSystemdMountFromFstabLine(fstab.NewFstabEntry("/var/swapfile", config.SwapMountPoint, "swap", "pri=10,discard", 0, 0))
+		assert.Equal(t, "var-swapfile.swap", m.UnitName())
+		assert.Contains(t, m.MountUnit(), "[Swap]\nWhat=/var/swapfile\nPriority=10\nOptions=discard\n")
+		assert.Contains(t, m.MountUnit(), "RequiredBy=swap.target")
+	})
+}
+
+func TestWriteSystemdUnits(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		entries := []*fstab.FstabLine{
+			fstab.NewFstabEntry("/dev/sda2", "/", "ext4", "defaults", 0, 1),
+			fstab.NewFstabEntry("192.168.4.5:/var/nfs/home", "/home", "nfs", "nofail,x-systemd.automount", 0, 0),
+		}
+		units, err := NewSystemdUnits(entries)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, len(units))
+
+		fsys := vfs.NewMemoryFS()
+		dir := "/etc/systemd/system"
+		assert.NoError(t, fsys.MkdirAll(dir, 0755))
+		assert.NoError(t, WriteSystemdUnits(fsys, units, dir))
+		// written twice to check existing links are replaced
+		assert.NoError(t, WriteSystemdUnits(fsys, units, dir))
+
+		for _, name := range []string{"-.mount", "home.mount", "home.automount"} {
+			_, err := fsys.Stat(filepath.Join(dir, name))
+			assert.NoError(t, err, name)
+		}
+		target, err := fsys.Readlink(filepath.Join(dir, "local-fs.target.requires", "-.mount"))
+		assert.NoError(t, err)
+		assert.Equal(t, "../-.mount", target)
+		_, err = fsys.Readlink(filepath.Join(dir, "remote-fs.target.wants", "home.automount"))
+		assert.NoError(t, err)
+	})
+
+	t.Run("duplicate unit", func(t *testing.T) {
+		entries := []*fstab.FstabLine{
+			fstab.NewFstabEntry("/dev/sdb1", "/var/lib/postgresql", "ext4", "defaults", 0, 2),
+			fstab.NewFstabEntry("/dev/sdb3", "/var/lib/postgresql", "ext4", "defaults", 0, 2),
+		}
+		_, err := NewSystemdUnits(entries)
+		assert.Error(t, err)
+	})
+}
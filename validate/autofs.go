@@ -0,0 +1,27 @@
+package validate
+
+import (
+	"fmt"
+	"path"
+
+	"tienbm90/yml2fstab/config"
+)
+
+func ValidateAutofsConfig(c *config.Config) error {
+	if c.Automount != config.AutomountAutofs {
+		return fmt.Errorf("unsupported automount %s for %s. Require %s", c.Automount, c.Source, config.AutomountAutofs)
+	}
+	if c.IsSwap() {
+		return fmt.Errorf("swap %s cannot be automounted", c.Source)
+	}
+	switch c.GetAutofsMap() {
+	case config.AutofsMapDirect:
+	case config.AutofsMapIndirect:
+		if path.Dir(c.GetMountPoint()) == "/" {
+			return fmt.Errorf("indirect autofs mount point %s must not be directly under /", c.GetMountPoint())
+		}
+	default:
+		return fmt.Errorf("unsupported autofs map %s for %s", c.AutofsMap, c.Source)
+	}
+	return nil
+}
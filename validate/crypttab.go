@@ -0,0 +1,30 @@
+package validate
+
+import (
+	"fmt"
+	"strings"
+
+	"tienbm90/yml2fstab/config"
+)
+
+func ValidateEncryptionConfig(c *config.Config) error {
+	e := c.Encryption
+	if !config.CheckMapperNameValid(e.Name) {
+		return fmt.Errorf("invalid mapper name %s", e.Name)
+	}
+	if c.Source != e.GetMapperDevice() {
+		return fmt.Errorf("encrypted entry %s must be mounted from %s", c.Source, e.GetMapperDevice())
+	}
+	if e.Device == "" || strings.ContainsAny(e.Device, " \t") {
+		return fmt.Errorf("invalid LUKS device %s for %s", e.Device, e.Name)
+	}
+	if e.Key != config.CrypttabKeyNone && !strings.HasPrefix(e.Key, "/") {
+		return fmt.Errorf("key of %s must be %s or an absolute path", e.Name, config.CrypttabKeyNone)
+	}
+	for _, opt := range e.Options {
+		if opt == "" || strings.ContainsAny(opt, ", \t") {
+			return fmt.Errorf("invalid crypttab option %q for %s", opt, e.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,4 @@
+// Package validate checks configs before they are rendered.
+//
+// ValidateConfigs stops at the first invalid config and returns an error naming its source.
+package validate
@@ -0,0 +1,25 @@
+package validate
+
+import "errors"
+
+// ErrInvalidConfig is matched by every error of ValidateConfig and ValidateConfigs.
+var ErrInvalidConfig = errors.New("invalid config")
+
+// ValidationError is a config that parsed but breaks a rule, Err names the rule.
+type ValidationError struct {
+	// Entry is the source of the config
+	Entry string
+	Err   error
+}
+
+func (e *ValidationError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidConfig
+}
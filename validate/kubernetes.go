@@ -0,0 +1,38 @@
+package validate
+
+import (
+	"fmt"
+	"regexp"
+
+	"tienbm90/yml2fstab/config"
+)
+
+// PVAccessModes are the access modes of a PersistentVolume.
+var PVAccessModes = []string{"ReadWriteOnce", "ReadOnlyMany", "ReadWriteMany", "ReadWriteOncePod"}
+
+var quantityRegexp = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?(Ki|Mi|Gi|Ti|Pi|Ei|k|M|G|T|P|E)?$`)
+
+func CheckQuantityValid(q string) bool {
+	return quantityRegexp.MatchString(q)
+}
+
+func CheckAccessModeValid(mode string) bool {
+	for _, m := range PVAccessModes {
+		if mode == m {
+			return true
+		}
+	}
+	return false
+}
+
+func ValidatePersistentVolumeConfig(c *config.Config) error {
+	if c.Capacity != "" && !CheckQuantityValid(c.Capacity) {
+		return fmt.Errorf("invalid capacity %s for %s", c.Capacity, c.Mount)
+	}
+	for _, mode := range c.AccessModes {
+		if !CheckAccessModeValid(mode) {
+			return fmt.Errorf("invalid access mode %s for %s", mode, c.Mount)
+		}
+	}
+	return nil
+}
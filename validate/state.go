@@ -0,0 +1,23 @@
+package validate
+
+import (
+	"fmt"
+
+	"tienbm90/yml2fstab/config"
+)
+
+func ValidateStateConfig(c *config.Config) error {
+	if !config.CheckStateValid(c.State) {
+		return fmt.Errorf("invalid state %s for %s", c.State, c.GetMountPoint())
+	}
+	if c.State != config.StateMounted && c.State != config.StateUnmounted {
+		return nil
+	}
+	if c.IsSwap() {
+		return fmt.Errorf("swap %s cannot be %s", c.Source, c.State)
+	}
+	if c.IsAutofs() {
+		return fmt.Errorf("%s is mounted by autofs and cannot be %s", c.GetMountPoint(), c.State)
+	}
+	return nil
+}
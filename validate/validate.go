@@ -0,0 +1,57 @@
+package validate
+
+import (
+	"fmt"
+
+	"tienbm90/yml2fstab/config"
+)
+
+// ValidateConfig checks c against the generic fstab rules and the hooks of its registered type.
+// Every error is a *ValidationError.
+func ValidateConfig(c *config.Config) error {
+	if err := validateConfig(c); err != nil {
+		return &ValidationError{Entry: c.Source, Err: err}
+	}
+	return nil
+}
+
+func validateConfig(c *config.Config) error {
+	if !config.CheckMountPointValid(c.GetMountPoint()) {
+		return fmt.Errorf("invalid mount point %s for %s", c.GetMountPoint(), c.Source)
+	}
+	if c.GetFileSystemType() == "" {
+		return fmt.Errorf("filesystem type not found for %s", c.Source)
+	}
+	t, ok := config.LookupFileSystemType(c.GetFileSystemType())
+	if !ok {
+		return fmt.Errorf("unsupported filesystem type %s for %s", c.GetFileSystemType(), c.Source)
+	}
+	if c.Automount != "" {
+		if err := ValidateAutofsConfig(c); err != nil {
+			return err
+		}
+	}
+	if c.Encryption != nil {
+		if err := ValidateEncryptionConfig(c); err != nil {
+			return err
+		}
+	}
+	if err := ValidatePersistentVolumeConfig(c); err != nil {
+		return err
+	}
+	if c.State != "" {
+		if err := ValidateStateConfig(c); err != nil {
+			return err
+		}
+	}
+	return t.Validate(c)
+}
+
+func ValidateConfigs(configs []*config.Config) error {
+	for _, c := range configs {
+		if err := ValidateConfig(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package validate
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+
+	"tienbm90/yml2fstab/config"
+)
+
+func TestValidateConfig(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		assert.NoError(t, ValidateConfig(config.NewConfigWithOptions(config.WithConfigSource("/dev/sda1"), config.WithConfigMount("/boot"), config.WithConfigFSType("xfs"))))
+		assert.NoError(t, ValidateConfig(config.NewConfigWithOptions(config.WithConfigSource("192.168.4.5"), config.WithConfigMount("/home"), config.WithConfigExport("/var/nfs/home"), config.WithConfigFSType("nfs"))))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		assert.Error(t, ValidateConfig(config.NewConfigWithOptions(config.WithConfigSource("/dev/sda1"), config.WithConfigMount("boot"), config.WithConfigFSType("xfs"))))
+		assert.Error(t, ValidateConfig(config.NewConfigWithOptions(config.WithConfigSource("/dev/sda1"), config.WithConfigMount("/boot"), config.WithConfigFSType("hdfs"))))
+		assert.Error(t, ValidateConfig(config.NewConfigWithOptions(config.WithConfigSource("/dev/sda1"), config.WithConfigMount("/boot"))))
+		assert.Error(t, ValidateConfig(config.NewConfigWithOptions(config.WithConfigSource("192.168.4.5"), config.WithConfigMount("/home"), config.WithConfigFSType("nfs"))))
+		assert.Error(t, ValidateConfig(config.NewConfigWithOptions(config.WithConfigSource("fileserver"), config.WithConfigMount("/share"), config.WithConfigFSType("cifs"))))
+		assert.Error(t, ValidateConfig(config.NewConfigWithOptions(config.WithConfigSource("swapfile"), config.WithConfigMount(config.SwapMountPoint), config.WithConfigFSType("swap"))))
+	})
+
+	t.Run("typed error", func(t *testing.T) {
+		err := ValidateConfigs([]*config.Config{config.NewConfigWithOptions(config.WithConfigSource("/dev/sda1"), config.WithConfigMount("boot"), config.WithConfigFSType("xfs"))})
+		assert.True(t, errors.Is(err, ErrInvalidConfig))
+		var verr *ValidationError
+		assert.True(t, errors.As(err, &verr))
+		assert.Equal(t, "/dev/sda1", verr.Entry)
+	})
+}
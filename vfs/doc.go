@@ -0,0 +1,6 @@
+// Package vfs is the file system every other package reads and writes through, the one
+// of the running system or one in memory for tests.
+//
+// The in memory file system can fail chosen operations on chosen files, so the error paths
+// of a write, a backup or a rename can be tested without a full disk or a read-only mount.
+package vfs
@@ -0,0 +1,131 @@
+package vfs
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// File is an open file of a FS.
+type File interface {
+	io.Reader
+	io.Writer
+	io.Closer
+	Name() string
+	// Sync commits the content written so far to stable storage.
+	Sync() error
+}
+
+// FS is a hierarchical file system, the methods behave as the functions of package os of
+// the same name. Errors are *os.PathError or *os.LinkError so os.IsNotExist and the like work.
+type FS interface {
+	Open(name string) (File, error)
+	OpenFile(name string, flag int, perm os.FileMode) (File, error)
+	// TempFile creates a new file in dir as ioutil.TempFile does.
+	TempFile(dir string, pattern string) (File, error)
+	Stat(name string) (os.FileInfo, error)
+	Lstat(name string) (os.FileInfo, error)
+	Readlink(name string) (string, error)
+	// ReadDir returns the entries of the directory dirname sorted by name, as ioutil.ReadDir.
+	ReadDir(dirname string) ([]os.FileInfo, error)
+	// EvalSymlinks returns the path name refers to, as filepath.EvalSymlinks.
+	EvalSymlinks(name string) (string, error)
+	MkdirAll(path string, perm os.FileMode) error
+	Chmod(name string, mode os.FileMode) error
+	Rename(oldpath string, newpath string) error
+	Remove(name string) error
+	Symlink(oldname string, newname string) error
+}
+
+// ReadFile returns the content of the file name of fsys.
+func ReadFile(fsys FS, name string) ([]byte, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ioutil.ReadAll(file)
+}
+
+// WriteFile writes data to the file name of fsys, creating it with perm when it does not
+// exist. The error of Close is returned too, a full disk is often only reported there.
+func WriteFile(fsys FS, name string, data []byte, perm os.FileMode) error {
+	file, err := fsys.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// osFS is the file system of the running system.
+type osFS struct{}
+
+// NewOSFS returns the file system of the running system.
+func NewOSFS() FS {
+	return osFS{}
+}
+
+func (osFS) Open(name string) (File, error) {
+	return openOSFile(os.Open(name))
+}
+
+func (osFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	return openOSFile(os.OpenFile(name, flag, perm))
+}
+
+func (osFS) TempFile(dir string, pattern string) (File, error) {
+	return openOSFile(ioutil.TempFile(dir, pattern))
+}
+
+// openOSFile keeps a nil *os.File from becoming a non nil File.
+func openOSFile(file *os.File, err error) (File, error) {
+	if err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+func (osFS) Stat(name string) (os.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (osFS) Lstat(name string) (os.FileInfo, error) {
+	return os.Lstat(name)
+}
+
+func (osFS) Readlink(name string) (string, error) {
+	return os.Readlink(name)
+}
+
+func (osFS) ReadDir(dirname string) ([]os.FileInfo, error) {
+	return ioutil.ReadDir(dirname)
+}
+
+func (osFS) EvalSymlinks(name string) (string, error) {
+	return filepath.EvalSymlinks(name)
+}
+
+func (osFS) MkdirAll(path string, perm os.FileMode) error {
+	return os.MkdirAll(path, perm)
+}
+
+func (osFS) Chmod(name string, mode os.FileMode) error {
+	return os.Chmod(name, mode)
+}
+
+func (osFS) Rename(oldpath string, newpath string) error {
+	return os.Rename(oldpath, newpath)
+}
+
+func (osFS) Remove(name string) error {
+	return os.Remove(name)
+}
+
+func (osFS) Symlink(oldname string, newname string) error {
+	return os.Symlink(oldname, newname)
+}
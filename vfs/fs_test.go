@@ -0,0 +1,152 @@
+package vfs
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+// testFS runs the same checks on the running system and in memory, so MemoryFS behaves as
+// the OS does where the packages rely on it.
+func testFS(t *testing.T, fsys FS, dir string) {
+	dst := filepath.Join(dir, "etc", "fstab")
+	assert.True(t, os.IsNotExist(WriteFile(fsys, dst, []byte("x"), 0644)))
+	assert.NoError(t, fsys.MkdirAll(filepath.Join(dir, "etc"), 0755))
+	assert.NoError(t, fsys.MkdirAll(filepath.Join(dir, "etc"), 0755))
+
+	assert.NoError(t, WriteFile(fsys, dst, []byte("/dev/sda1 /boot xfs defaults 0 0\n"), 0600))
+	content, err := ReadFile(fsys, dst)
+	assert.NoError(t, err)
+	assert.Equal(t, "/dev/sda1 /boot xfs defaults 0 0\n", string(content))
+	info, err := fsys.Stat(dst)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	assert.Equal(t, "fstab", info.Name())
+	assert.NoError(t, fsys.Chmod(dst, 0644))
+	info, _ = fsys.Stat(dst)
+	assert.Equal(t, os.FileMode(0644), info.Mode().Perm())
+
+	_, err = fsys.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	assert.True(t, os.IsExist(err))
+	_, err = fsys.OpenFile(filepath.Join(dir, "etc"), os.O_WRONLY, 0644)
+	assert.Error(t, err)
+	_, err = fsys.Open(filepath.Join(dst, "child"))
+	assert.Error(t, err)
+
+	// symlinks
+	link := filepath.Join(dir, "fstab")
+	assert.NoError(t, fsys.Symlink(filepath.Join("etc", "fstab"), link))
+	assert.True(t, os.IsExist(fsys.Symlink("etc", link)))
+	target, err := fsys.Readlink(link)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join("etc", "fstab"), target)
+	_, err = fsys.Readlink(dst)
+	assert.Error(t, err)
+	info, _ = fsys.Lstat(link)
+	assert.True(t, info.Mode()&os.ModeSymlink != 0)
+	info, _ = fsys.Stat(link)
+	assert.True(t, info.Mode().IsRegular())
+	content, _ = ReadFile(fsys, link)
+	assert.Equal(t, "/dev/sda1 /boot xfs defaults 0 0\n", string(content))
+	resolved, err := fsys.EvalSymlinks(link)
+	assert.NoError(t, err)
+	expected, _ := fsys.EvalSymlinks(dst)
+	assert.Equal(t, expected, resolved)
+	assert.Equal(t, "fstab", filepath.Base(resolved))
+	_, err = fsys.EvalSymlinks(filepath.Join(dir, "missing"))
+	assert.True(t, os.IsNotExist(err))
+
+	infos, err := fsys.ReadDir(dir)
+	assert.NoError(t, err)
+	if assert.Len(t, infos, 2) {
+		assert.Equal(t, "etc", infos[0].Name())
+		assert.True(t, infos[0].IsDir())
+		assert.Equal(t, "fstab", infos[1].Name())
+		assert.True(t, infos[1].Mode()&os.ModeSymlink != 0)
+	}
+	_, err = fsys.ReadDir(dst)
+	assert.Error(t, err)
+	_, err = fsys.ReadDir(filepath.Join(dir, "missing"))
+	assert.True(t, os.IsNotExist(err))
+
+	// a temp file renamed over the file
+	tmp, err := fsys.TempFile(filepath.Join(dir, "etc"), ".fstab.tmp-")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "etc"), filepath.Dir(tmp.Name()))
+	_, err = tmp.Write([]byte("new\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, tmp.Sync())
+	assert.NoError(t, tmp.Close())
+	assert.Error(t, tmp.Close())
+	assert.NoError(t, fsys.Rename(tmp.Name(), dst))
+	content, _ = ReadFile(fsys, link)
+	assert.Equal(t, "new\n", string(content))
+	_, err = fsys.Stat(tmp.Name())
+	assert.True(t, os.IsNotExist(err))
+
+	assert.Error(t, fsys.Remove(filepath.Join(dir, "etc")))
+	assert.NoError(t, fsys.Remove(link))
+	assert.NoError(t, fsys.Remove(dst))
+	assert.True(t, os.IsNotExist(fsys.Remove(dst)))
+	assert.NoError(t, fsys.Remove(filepath.Join(dir, "etc")))
+}
+
+func TestFS(t *testing.T) {
+	t.Run("os", func(t *testing.T) {
+		testFS(t, NewOSFS(), t.TempDir())
+	})
+
+	t.Run("memory", func(t *testing.T) {
+		fsys := NewMemoryFS()
+		testFS(t, fsys, "/tmp")
+		assert.Equal(t, []string{"/tmp"}, fsys.Paths())
+	})
+}
+
+func TestMemoryFS(t *testing.T) {
+	t.Run("absolute symlinks and loops", func(t *testing.T) {
+		fsys := NewMemoryFS()
+		assert.NoError(t, fsys.MkdirAll("/usr/etc", 0755))
+		assert.NoError(t, fsys.Symlink("/usr/etc", "/etc"))
+		assert.NoError(t, WriteFile(fsys, "/etc/fstab", []byte("x"), 0644))
+		assert.Equal(t, []string{"/etc", "/usr", "/usr/etc", "/usr/etc/fstab"}, fsys.Paths())
+
+		assert.NoError(t, fsys.Symlink("/b", "/a"))
+		assert.NoError(t, fsys.Symlink("/a", "/b"))
+		_, err := fsys.Stat("/a/fstab")
+		assert.True(t, errors.Is(err, syscall.ELOOP))
+	})
+
+	t.Run("faults", func(t *testing.T) {
+		fsys := NewMemoryFS()
+		full := errors.New("no space left on device")
+		fsys.Fail(OpWrite, "/etc/*", full)
+		fsys.Fail(OpClose, "/etc/crypttab", syscall.EIO)
+		fsys.Fail(OpRename, "/etc/fstab", syscall.EROFS)
+		assert.NoError(t, fsys.MkdirAll("/etc", 0755))
+
+		err := WriteFile(fsys, "/etc/fstab", []byte("x"), 0644)
+		assert.True(t, errors.Is(err, full))
+		content, _ := ReadFile(fsys, "/etc/fstab")
+		assert.Empty(t, content)
+
+		// close errors are not lost
+		file, err := fsys.OpenFile("/etc/crypttab", os.O_RDWR|os.O_CREATE, 0644)
+		assert.NoError(t, err)
+		assert.True(t, errors.Is(file.Close(), syscall.EIO))
+
+		assert.NoError(t, WriteFile(fsys, "/fstab", []byte("x"), 0644))
+		err = fsys.Rename("/fstab", "/etc/fstab")
+		assert.True(t, errors.Is(err, syscall.EROFS))
+		var lerr *os.LinkError
+		assert.True(t, errors.As(err, &lerr))
+		_, err = fsys.Stat("/fstab")
+		assert.NoError(t, err)
+
+		fsys.ClearFaults()
+		assert.NoError(t, WriteFile(fsys, "/etc/fstab", []byte("x"), 0644))
+	})
+}
@@ -0,0 +1,529 @@
+package vfs
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Op names an operation of MemoryFS a fault can be injected into.
+type Op string
+
+const (
+	// OpOpen covers Open, OpenFile and TempFile.
+	OpOpen  Op = "open"
+	OpRead  Op = "read"
+	OpWrite Op = "write"
+	OpSync  Op = "sync"
+	OpClose Op = "close"
+	// OpStat covers Stat, Lstat and EvalSymlinks.
+	OpStat     Op = "stat"
+	OpReadlink Op = "readlink"
+	OpReadDir  Op = "readdir"
+	OpMkdir    Op = "mkdir"
+	OpChmod    Op = "chmod"
+	// OpRename matches the old and the new path.
+	OpRename Op = "rename"
+	OpRemove Op = "remove"
+	// OpSymlink matches the path of the link.
+	OpSymlink Op = "symlink"
+)
+
+type fault struct {
+	op      Op
+	pattern string
+	err     error
+}
+
+type memoryNode struct {
+	// mode holds os.ModeDir or os.ModeSymlink besides the permissions
+	mode    os.FileMode
+	data    []byte
+	target  string
+	modTime time.Time
+}
+
+// MemoryFS is a FS kept in memory. Paths are absolute, a relative name starts at /.
+type MemoryFS struct {
+	mu     sync.Mutex
+	nodes  map[string]*memoryNode
+	faults []fault
+	temp   int
+}
+
+// NewMemoryFS returns an empty MemoryFS holding only the / directory.
+func NewMemoryFS() *MemoryFS {
+	return &MemoryFS{nodes: map[string]*memoryNode{
+		"/": {mode: os.ModeDir | 0755, modTime: time.Now()},
+	}}
+}
+
+// Fail makes op fail with err on the paths matching pattern, a filepath.Match pattern.
+// A failed write writes nothing and a failed close still closes the file.
+func (m *MemoryFS) Fail(op Op, pattern string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.faults = append(m.faults, fault{op: op, pattern: pattern, err: err})
+}
+
+// ClearFaults removes every fault added by Fail.
+func (m *MemoryFS) ClearFaults() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.faults = nil
+}
+
+// Paths returns the path of every file, directory and symlink but /, sorted.
+func (m *MemoryFS) Paths() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	paths := make([]string, 0, len(m.nodes))
+	for p := range m.nodes {
+		if p != "/" {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// fault returns the error injected into op on one of paths.
+func (m *MemoryFS) fault(op Op, paths ...string) error {
+	for _, f := range m.faults {
+		if f.op != op {
+			continue
+		}
+		for _, p := range paths {
+			if ok, _ := filepath.Match(f.pattern, p); ok {
+				return f.err
+			}
+		}
+	}
+	return nil
+}
+
+func clean(name string) string {
+	return filepath.Join("/", name)
+}
+
+// resolve returns the path of name without symlinks, the last element is only followed
+// with followLast. The parent of the returned path is an existing directory.
+func (m *MemoryFS) resolve(name string, followLast bool) (string, error) {
+	return Resolve(clean(name), followLast, func(p string) (string, bool, error) {
+		parent, ok := m.nodes[filepath.Dir(p)]
+		if !ok {
+			return "", false, syscall.ENOENT
+		}
+		if !parent.mode.IsDir() {
+			return "", false, syscall.ENOTDIR
+		}
+		node, ok := m.nodes[p]
+		if !ok || node.mode&os.ModeSymlink == 0 {
+			return "", false, nil
+		}
+		return node.target, true, nil
+	})
+}
+
+// lookup returns the resolved path of name and its node.
+func (m *MemoryFS) lookup(name string, followLast bool) (string, *memoryNode, error) {
+	p, err := m.resolve(name, followLast)
+	if err != nil {
+		return "", nil, err
+	}
+	node, ok := m.nodes[p]
+	if !ok {
+		return p, nil, syscall.ENOENT
+	}
+	return p, node, nil
+}
+
+// isEmptyDir reports whether nothing is stored below the directory p.
+func (m *MemoryFS) isEmptyDir(p string) bool {
+	for child := range m.nodes {
+		if child != p && strings.HasPrefix(child, p+"/") {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *MemoryFS) Open(name string) (File, error) {
+	return m.OpenFile(name, os.O_RDONLY, 0)
+}
+
+func (m *MemoryFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.openFile(name, flag, perm)
+}
+
+func (m *MemoryFS) openFile(name string, flag int, perm os.FileMode) (File, error) {
+	pathError := func(err error) error {
+		return &os.PathError{Op: "open", Path: name, Err: err}
+	}
+	p, node, err := m.lookup(name, true)
+	if err != nil && node == nil && p == "" {
+		return nil, pathError(err)
+	}
+	if err := m.fault(OpOpen, clean(name), p); err != nil {
+		return nil, pathError(err)
+	}
+
+	writable := flag&(os.O_WRONLY|os.O_RDWR) != 0
+	switch {
+	case node == nil && flag&os.O_CREATE == 0:
+		return nil, pathError(syscall.ENOENT)
+	case node == nil:
+		node = &memoryNode{mode: perm.Perm(), modTime: time.Now()}
+		m.nodes[p] = node
+	case flag&os.O_CREATE != 0 && flag&os.O_EXCL != 0:
+		return nil, pathError(syscall.EEXIST)
+	case node.mode.IsDir() && writable:
+		return nil, pathError(syscall.EISDIR)
+	case flag&os.O_TRUNC != 0 && writable:
+		node.data = nil
+		node.modTime = time.Now()
+	}
+	return &memoryFile{
+		fs:       m,
+		name:     name,
+		path:     p,
+		node:     node,
+		readable: flag&os.O_WRONLY == 0,
+		writable: writable,
+		append:   flag&os.O_APPEND != 0,
+	}, nil
+}
+
+func (m *MemoryFS) TempFile(dir string, pattern string) (File, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	prefix, suffix := pattern, ""
+	if i := strings.LastIndex(pattern, "*"); i >= 0 {
+		prefix, suffix = pattern[:i], pattern[i+1:]
+	}
+	for {
+		m.temp++
+		name := filepath.Join(dir, prefix+strconv.Itoa(m.temp)+suffix)
+		file, err := m.openFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0600)
+		if os.IsExist(err) {
+			continue
+		}
+		return file, err
+	}
+}
+
+func (m *MemoryFS) Stat(name string) (os.FileInfo, error) {
+	return m.stat("stat", name, true)
+}
+
+func (m *MemoryFS) Lstat(name string) (os.FileInfo, error) {
+	return m.stat("lstat", name, false)
+}
+
+func (m *MemoryFS) stat(op string, name string, follow bool) (os.FileInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, node, err := m.lookup(name, follow)
+	if ferr := m.fault(OpStat, clean(name), p); ferr != nil {
+		err = ferr
+	}
+	if err != nil {
+		return nil, &os.PathError{Op: op, Path: name, Err: err}
+	}
+	return &memoryFileInfo{name: filepath.Base(p), node: *node}, nil
+}
+
+func (m *MemoryFS) Readlink(name string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, node, err := m.lookup(name, false)
+	if ferr := m.fault(OpReadlink, clean(name), p); ferr != nil {
+		err = ferr
+	}
+	if err == nil && node.mode&os.ModeSymlink == 0 {
+		err = syscall.EINVAL
+	}
+	if err != nil {
+		return "", &os.PathError{Op: "readlink", Path: name, Err: err}
+	}
+	return node.target, nil
+}
+
+func (m *MemoryFS) ReadDir(dirname string) ([]os.FileInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, node, err := m.lookup(dirname, true)
+	if ferr := m.fault(OpReadDir, clean(dirname), p); ferr != nil {
+		err = ferr
+	}
+	if err == nil && !node.mode.IsDir() {
+		err = syscall.ENOTDIR
+	}
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: dirname, Err: err}
+	}
+	infos := make([]os.FileInfo, 0)
+	for child, node := range m.nodes {
+		if child != p && filepath.Dir(child) == p {
+			infos = append(infos, &memoryFileInfo{name: filepath.Base(child), node: *node})
+		}
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name() < infos[j].Name() })
+	return infos, nil
+}
+
+func (m *MemoryFS) EvalSymlinks(name string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, _, err := m.lookup(name, true)
+	if ferr := m.fault(OpStat, clean(name), p); ferr != nil {
+		err = ferr
+	}
+	if err != nil {
+		return "", &os.PathError{Op: "lstat", Path: name, Err: err}
+	}
+	return p, nil
+}
+
+func (m *MemoryFS) MkdirAll(path string, perm os.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	pathError := func(err error) error {
+		return &os.PathError{Op: "mkdir", Path: path, Err: err}
+	}
+	if err := m.fault(OpMkdir, clean(path)); err != nil {
+		return pathError(err)
+	}
+	current := "/"
+	for _, elem := range strings.Split(clean(path), "/") {
+		if elem == "" {
+			continue
+		}
+		p, node, err := m.lookup(filepath.Join(current, elem), true)
+		switch {
+		case node != nil && !node.mode.IsDir():
+			return pathError(syscall.ENOTDIR)
+		case node == nil && p == "":
+			return pathError(err)
+		case node == nil:
+			m.nodes[p] = &memoryNode{mode: os.ModeDir | perm.Perm(), modTime: time.Now()}
+		}
+		current = p
+	}
+	return nil
+}
+
+func (m *MemoryFS) Chmod(name string, mode os.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, node, err := m.lookup(name, true)
+	if ferr := m.fault(OpChmod, clean(name), p); ferr != nil {
+		err = ferr
+	}
+	if err != nil {
+		return &os.PathError{Op: "chmod", Path: name, Err: err}
+	}
+	node.mode = node.mode&^os.ModePerm | mode.Perm()
+	return nil
+}
+
+func (m *MemoryFS) Rename(oldpath string, newpath string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	linkError := func(err error) error {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: err}
+	}
+	op, node, err := m.lookup(oldpath, false)
+	if err != nil {
+		return linkError(err)
+	}
+	np, existing, err := m.lookup(newpath, false)
+	if np == "" {
+		return linkError(err)
+	}
+	if err := m.fault(OpRename, clean(oldpath), op, clean(newpath), np); err != nil {
+		return linkError(err)
+	}
+	if op == np {
+		return nil
+	}
+	if existing != nil && existing.mode.IsDir() && (!node.mode.IsDir() || !m.isEmptyDir(np)) {
+		return linkError(syscall.EISDIR)
+	}
+	if node.mode.IsDir() && strings.HasPrefix(np, op+"/") {
+		return linkError(syscall.EINVAL)
+	}
+
+	delete(m.nodes, op)
+	m.nodes[np] = node
+	if node.mode.IsDir() {
+		for child, n := range m.nodes {
+			if strings.HasPrefix(child, op+"/") {
+				delete(m.nodes, child)
+				m.nodes[np+strings.TrimPrefix(child, op)] = n
+			}
+		}
+	}
+	return nil
+}
+
+func (m *MemoryFS) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, node, err := m.lookup(name, false)
+	if ferr := m.fault(OpRemove, clean(name), p); ferr != nil {
+		err = ferr
+	}
+	if err == nil && node.mode.IsDir() && !m.isEmptyDir(p) {
+		err = syscall.ENOTEMPTY
+	}
+	if err == nil && p == "/" {
+		err = syscall.EBUSY
+	}
+	if err != nil {
+		return &os.PathError{Op: "remove", Path: name, Err: err}
+	}
+	delete(m.nodes, p)
+	return nil
+}
+
+func (m *MemoryFS) Symlink(oldname string, newname string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	linkError := func(err error) error {
+		return &os.LinkError{Op: "symlink", Old: oldname, New: newname, Err: err}
+	}
+	p, node, err := m.lookup(newname, false)
+	switch {
+	case p == "":
+		return linkError(err)
+	case node != nil:
+		return linkError(syscall.EEXIST)
+	}
+	if err := m.fault(OpSymlink, clean(newname), p); err != nil {
+		return linkError(err)
+	}
+	m.nodes[p] = &memoryNode{mode: os.ModeSymlink | 0777, target: oldname, modTime: time.Now()}
+	return nil
+}
+
+// memoryFile is an open file of a MemoryFS, it keeps working once the file is renamed
+// or removed.
+type memoryFile struct {
+	fs       *MemoryFS
+	name     string
+	path     string
+	node     *memoryNode
+	offset   int
+	readable bool
+	writable bool
+	append   bool
+	closed   bool
+}
+
+func (f *memoryFile) Name() string {
+	return f.name
+}
+
+// check returns the error of op on f, ok is false when f does not allow it.
+func (f *memoryFile) check(op Op, ok bool) error {
+	var err error
+	switch {
+	case f.closed:
+		err = os.ErrClosed
+	case !ok:
+		err = syscall.EBADF
+	default:
+		err = f.fs.fault(op, clean(f.name), f.path)
+	}
+	if err != nil {
+		return &os.PathError{Op: string(op), Path: f.name, Err: err}
+	}
+	return nil
+}
+
+func (f *memoryFile) Read(b []byte) (int, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	if err := f.check(OpRead, f.readable); err != nil {
+		return 0, err
+	}
+	if f.offset >= len(f.node.data) {
+		return 0, io.EOF
+	}
+	n := copy(b, f.node.data[f.offset:])
+	f.offset += n
+	return n, nil
+}
+
+func (f *memoryFile) Write(b []byte) (int, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	if err := f.check(OpWrite, f.writable); err != nil {
+		return 0, err
+	}
+	if f.append {
+		f.offset = len(f.node.data)
+	}
+	if end := f.offset + len(b); end > len(f.node.data) {
+		f.node.data = append(f.node.data, make([]byte, end-len(f.node.data))...)
+	}
+	n := copy(f.node.data[f.offset:], b)
+	f.offset += n
+	f.node.modTime = time.Now()
+	return n, nil
+}
+
+func (f *memoryFile) Sync() error {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	return f.check(OpSync, true)
+}
+
+func (f *memoryFile) Close() error {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	err := f.check(OpClose, true)
+	f.closed = true
+	return err
+}
+
+type memoryFileInfo struct {
+	name string
+	node memoryNode
+}
+
+func (i *memoryFileInfo) Name() string {
+	return i.name
+}
+
+func (i *memoryFileInfo) Size() int64 {
+	if i.node.mode&os.ModeSymlink != 0 {
+		return int64(len(i.node.target))
+	}
+	return int64(len(i.node.data))
+}
+
+func (i *memoryFileInfo) Mode() os.FileMode {
+	return i.node.mode
+}
+
+func (i *memoryFileInfo) ModTime() time.Time {
+	return i.node.modTime
+}
+
+func (i *memoryFileInfo) IsDir() bool {
+	return i.node.mode.IsDir()
+}
+
+func (i *memoryFileInfo) Sys() interface{} {
+	return nil
+}
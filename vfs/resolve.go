@@ -0,0 +1,85 @@
+package vfs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// MaxSymlinks is the number of links followed before a path is taken for a loop.
+const MaxSymlinks = 255
+
+// ReadlinkFunc is called by Resolve for every component of a path. It returns the target of
+// the symlink at path, ok is false when path is not a symlink or does not exist.
+type ReadlinkFunc func(path string) (target string, ok bool, err error)
+
+// Resolve returns name without symlinks, following them the way the kernel does: a relative
+// target is read from the directory of the link and an absolute one restarts at /. The last
+// component is only followed with followLast, components that do not exist are kept as they
+// are. It fails with syscall.ELOOP after MaxSymlinks links.
+func Resolve(name string, followLast bool, readlink ReadlinkFunc) (string, error) {
+	resolved := "."
+	if filepath.IsAbs(name) {
+		resolved = "/"
+	}
+	rest := strings.Split(name, "/")
+	links := 0
+	for len(rest) > 0 {
+		elem := rest[0]
+		rest = rest[1:]
+		switch {
+		case elem == "" || elem == ".":
+			continue
+		case elem == ".." && (resolved == "." || filepath.Base(resolved) == ".."):
+			resolved = filepath.Join(resolved, elem)
+			continue
+		case elem == "..":
+			resolved = filepath.Dir(resolved)
+			continue
+		}
+
+		next := filepath.Join(resolved, elem)
+		target, ok, err := readlink(next)
+		if err != nil {
+			return "", err
+		}
+		if !ok || (strings.Join(rest, "") == "" && !followLast) {
+			resolved = next
+			continue
+		}
+
+		links++
+		if links > MaxSymlinks {
+			return "", syscall.ELOOP
+		}
+		if filepath.IsAbs(target) {
+			resolved = "/"
+		}
+		rest = append(strings.Split(target, "/"), rest...)
+	}
+	return resolved, nil
+}
+
+// LinkReader returns a ReadlinkFunc reading the symlinks of fsys. The paths are taken inside
+// root when it is not empty, as in a chroot.
+func LinkReader(fsys FS, root string) ReadlinkFunc {
+	return func(path string) (string, bool, error) {
+		full := filepath.Join(root, path)
+		info, err := fsys.Lstat(full)
+		if os.IsNotExist(err) {
+			return "", false, nil
+		}
+		if err != nil {
+			return "", false, err
+		}
+		if info.Mode()&os.ModeSymlink == 0 {
+			return "", false, nil
+		}
+		target, err := fsys.Readlink(full)
+		if err != nil {
+			return "", false, err
+		}
+		return target, true, nil
+	}
+}
@@ -0,0 +1,63 @@
+package vfs
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"syscall"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	links := map[string]string{
+		"/etc":          "/usr/etc",
+		"/usr/etc/link": "../lib/fstab",
+		"/a":            "/b",
+		"/b":            "/a",
+		"data":          "../srv/data",
+	}
+	readlink := func(p string) (string, bool, error) {
+		target, ok := links[p]
+		return target, ok, nil
+	}
+
+	t.Run("success", func(t *testing.T) {
+		for name, expected := range map[string]string{
+			"/etc/fstab":          "/usr/etc/fstab",
+			"/etc/link":           "/usr/lib/fstab",
+			"/etc/../fstab":       "/usr/fstab",
+			"/../../etc/crypttab": "/usr/etc/crypttab",
+			"fstab":               "fstab",
+			"data/fstab":          "../srv/data/fstab",
+			"../../out":           "../../out",
+		} {
+			resolved, err := Resolve(name, true, readlink)
+			assert.NoError(t, err, name)
+			assert.Equal(t, expected, resolved, name)
+		}
+	})
+
+	t.Run("last element", func(t *testing.T) {
+		resolved, err := Resolve("/etc/link", false, readlink)
+		assert.NoError(t, err)
+		assert.Equal(t, "/usr/etc/link", resolved)
+	})
+
+	t.Run("loop", func(t *testing.T) {
+		_, err := Resolve("/a/fstab", true, readlink)
+		assert.Equal(t, syscall.ELOOP, err)
+	})
+
+	t.Run("link reader", func(t *testing.T) {
+		fsys := NewMemoryFS()
+		assert.NoError(t, fsys.MkdirAll("/image/usr/etc", 0755))
+		assert.NoError(t, fsys.Symlink("/usr/etc", "/image/etc"))
+
+		resolved, err := Resolve("/etc/fstab", true, LinkReader(fsys, "/image"))
+		assert.NoError(t, err)
+		assert.Equal(t, "/usr/etc/fstab", resolved)
+
+		fsys.Fail(OpStat, "/image/etc", syscall.EACCES)
+		_, err = Resolve("/etc/fstab", true, LinkReader(fsys, "/image"))
+		assert.True(t, errors.Is(err, syscall.EACCES))
+	})
+}